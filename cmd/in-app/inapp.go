@@ -14,7 +14,8 @@ import (
 func main() {
 	wd, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		fmt.Println("error", err)
+		os.Exit(1)
 	}
 
 	migrationsUrl := filepath.Join(wd, "../../examples/db/workspace")
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/logging"
 	"github.com/djordjev/pg-mig/models"
 	"github.com/djordjev/pg-mig/timer"
 	"github.com/spf13/afero"
+	"log/slog"
 	"os"
 	"time"
 
@@ -15,9 +18,22 @@ import (
 const ConfigDirEnv = "PG_MIG_CONFIG_DIR"
 
 func main() {
-	printer := subcommands.ImplPrinter{NoColor: true}
+	flagSet := flag.NewFlagSet("pg-mig", flag.ExitOnError)
 
-	if len(os.Args) < 2 {
+	verbose := flagSet.Bool("v", false, "Verbose output including debug logs of connection attempts and queries")
+	quiet := flagSet.Bool("q", false, "Print only errors")
+	logFormat := flagSet.String("log-format", logging.FormatText, "Format of the output: text or json")
+
+	_ = flagSet.Parse(os.Args[1:])
+	args := flagSet.Args()
+
+	printer, logger, err := buildOutput(*verbose, *quiet, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if len(args) < 1 {
 		printer.PrintError("Missing command. Please run pg-mig help to see more info")
 		os.Exit(1)
 	}
@@ -25,26 +41,43 @@ func main() {
 	configDir := os.Getenv(ConfigDirEnv)
 
 	runner := subcommands.Runner{
-		Subcommand: os.Args[1],
-		Flags:      os.Args[2:],
+		Subcommand: args[0],
+		Flags:      args[1:],
 		Fs:         &filesystem.ImplFilesystem{Fs: afero.NewOsFs(), GetNow: time.Now, ConfigDir: configDir},
-		Connector:  models.BuildConnector,
+		Connector:  models.NewConnector(logger),
 		Timer:      timer.Timer{Now: time.Now},
-		Printer:    &printer,
+		Printer:    printer,
 	}
 
-	defer func() {
-		if e := recover(); e != nil {
-			fmt.Println("execution error: ", e)
-			os.Exit(1)
-		} else {
-			os.Exit(0)
-		}
-	}()
-
-	err := runner.Run()
+	err = runner.Run()
 	if err != nil {
 		printer.PrintError(err.Error())
 		os.Exit(1)
 	}
 }
+
+// buildOutput returns printer for command output and logger for debug logs. With JSON
+// format both of them write log records, otherwise output is human readable and logs
+// are written to stderr.
+func buildOutput(verbose bool, quiet bool, format string) (subcommands.Printer, *slog.Logger, error) {
+	level, err := logging.Level(verbose, quiet)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format == logging.FormatJSON {
+		logger, err := logging.New(os.Stdout, os.Stderr, logging.Options{Level: level, Format: format})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return &subcommands.LogPrinter{Logger: logger}, logger, nil
+	}
+
+	logger, err := logging.New(os.Stderr, os.Stderr, logging.Options{Level: level, Format: format})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &subcommands.ImplPrinter{NoColor: true, Quiet: quiet}, logger, nil
+}
@@ -0,0 +1,157 @@
+package dump
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+const defaultPgDump = "pg_dump"
+
+const defaultPgRestore = "pg_restore"
+
+const defaultMetaTable = "__pg_mig_meta"
+
+const seedsTable = "__pg_mig_seeds"
+
+// PgDump invokes locally installed pg_dump and pg_restore executables
+type PgDump struct {
+	Path        string
+	RestorePath string
+	MetaTable   string
+}
+
+func (d *PgDump) executable() string {
+	if d.Path == "" {
+		return defaultPgDump
+	}
+
+	return d.Path
+}
+
+func (d *PgDump) restoreExecutable() string {
+	if d.RestorePath == "" {
+		return defaultPgRestore
+	}
+
+	return d.RestorePath
+}
+
+// DumpSchema returns schema-only SQL dump of the database behind connection string
+// that can be executed as a migration
+func (d *PgDump) DumpSchema(connString string) (string, error) {
+	schema, err := d.run(d.executable(), schemaArgs(connString, d.metaTable()))
+	if err != nil {
+		return "", err
+	}
+
+	return cleanSchema(schema), nil
+}
+
+// psqlMetaCommandRegex matches psql meta-commands (\restrict, \connect...) which are not SQL
+var psqlMetaCommandRegex = regexp.MustCompile(`^\\[a-z]+`)
+
+// sessionSetRegex matches SET statements of pg_dump header which change settings of the whole session
+var sessionSetRegex = regexp.MustCompile(`^SET (\w+)`)
+
+// setConfigRegex matches set_config call of pg_dump header changing setting of the whole session
+var setConfigRegex = regexp.MustCompile(`^(SELECT pg_catalog\.set_config\('\w+', '[^']*', )false\);`)
+
+// cleanSchema prepares output of pg_dump for executing as a migration. Migrations share
+// connection so settings are limited to migration transaction and psql meta-commands are removed.
+func cleanSchema(schema string) string {
+	lines := strings.Split(schema, "\n")
+	result := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if psqlMetaCommandRegex.MatchString(line) {
+			continue
+		}
+
+		line = sessionSetRegex.ReplaceAllString(line, "SET LOCAL $1")
+		line = setConfigRegex.ReplaceAllString(line, "${1}true);")
+
+		result = append(result, line)
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// Backup writes full dump of the database (or only of given schema when not empty)
+// in pg_dump custom format into file
+func (d *PgDump) Backup(connString string, schema string, file string) error {
+	_, err := d.run(d.executable(), backupArgs(connString, schema, file))
+	return err
+}
+
+// Restore restores backup created with Backup into the database behind connection string.
+// Existing objects from the backup are dropped first and everything runs in a single transaction.
+func (d *PgDump) Restore(connString string, file string) error {
+	_, err := d.run(d.restoreExecutable(), restoreArgs(connString, file))
+	return err
+}
+
+func (d *PgDump) metaTable() string {
+	if d.MetaTable == "" {
+		return defaultMetaTable
+	}
+
+	return d.MetaTable
+}
+
+func (d *PgDump) run(executable string, args []string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(context.Background(), executable, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("dump error: %s failed %w %s", executable, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// schemaArgs excludes meta and seeds tables in every schema, as migrations or tenants may
+// keep their own copies in schemas other than the configured one
+func schemaArgs(connString string, metaTable string) []string {
+	name := metaTable[strings.LastIndex(metaTable, ".")+1:]
+
+	return []string{
+		"--schema-only",
+		"--no-owner",
+		"--no-privileges",
+		"--exclude-table=*." + name,
+		"--exclude-table=*." + seedsTable,
+		"--dbname=" + connString,
+	}
+}
+
+func backupArgs(connString string, schema string, file string) []string {
+	args := []string{
+		"--format=custom",
+		"--file=" + file,
+	}
+
+	if schema != "" {
+		args = append(args, "--schema="+schema)
+	}
+
+	return append(args, "--dbname="+connString)
+}
+
+func restoreArgs(connString string, file string) []string {
+	return []string{
+		"--clean",
+		"--if-exists",
+		"--single-transaction",
+		"--no-owner",
+		"--dbname=" + connString,
+		file,
+	}
+}
@@ -0,0 +1,114 @@
+package dump
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSchemaArgs(t *testing.T) {
+	r := require.New(t)
+
+	d := PgDump{}
+	args := schemaArgs("postgres://u:p@localhost:5432/db", d.metaTable())
+
+	r.Contains(args, "--schema-only")
+	r.Contains(args, "--exclude-table=*.__pg_mig_meta")
+	r.Contains(args, "--exclude-table=*.__pg_mig_seeds")
+	r.Equal("--dbname=postgres://u:p@localhost:5432/db", args[len(args)-1])
+
+	d = PgDump{MetaTable: "admin.billing"}
+	args = schemaArgs("postgres://u:p@localhost:5432/db", d.metaTable())
+
+	r.Contains(args, "--exclude-table=*.billing")
+	r.Contains(args, "--exclude-table=*.__pg_mig_seeds")
+	r.Equal("--dbname=postgres://u:p@localhost:5432/db", args[len(args)-1])
+}
+
+func TestDumpSchemaMissingExecutable(t *testing.T) {
+	r := require.New(t)
+
+	d := PgDump{Path: "/nonexistent/pg_dump"}
+	_, err := d.DumpSchema("postgres://u:p@localhost:5432/db")
+
+	r.Error(err)
+}
+
+func TestCleanSchema(t *testing.T) {
+	r := require.New(t)
+
+	schema := `--
+-- PostgreSQL database dump
+--
+
+\restrict abc123
+
+SET statement_timeout = 0;
+SET check_function_bodies = false;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET default_tablespace = '';
+
+CREATE TABLE public.users (
+    id integer NOT NULL
+);
+
+\unrestrict abc123
+`
+
+	expected := `--
+-- PostgreSQL database dump
+--
+
+
+SET LOCAL statement_timeout = 0;
+SET LOCAL check_function_bodies = false;
+SELECT pg_catalog.set_config('search_path', '', true);
+SET LOCAL default_tablespace = '';
+
+CREATE TABLE public.users (
+    id integer NOT NULL
+);
+
+`
+
+	r.Equal(expected, cleanSchema(schema))
+}
+
+func TestBackupArgs(t *testing.T) {
+	r := require.New(t)
+
+	args := backupArgs("postgres://u:p@localhost:5432/db", "", "backups/db_20201020T100000Z.dump")
+	r.Equal([]string{
+		"--format=custom",
+		"--file=backups/db_20201020T100000Z.dump",
+		"--dbname=postgres://u:p@localhost:5432/db",
+	}, args)
+
+	args = backupArgs("postgres://u:p@localhost:5432/db", "tenant_a", "backups/db_tenant_a.dump")
+	r.Contains(args, "--schema=tenant_a")
+	r.Equal("--dbname=postgres://u:p@localhost:5432/db", args[len(args)-1])
+}
+
+func TestRestoreArgs(t *testing.T) {
+	r := require.New(t)
+
+	args := restoreArgs("postgres://u:p@localhost:5432/db", "backups/db.dump")
+
+	r.Contains(args, "--clean")
+	r.Contains(args, "--single-transaction")
+	r.Contains(args, "--dbname=postgres://u:p@localhost:5432/db")
+	r.Equal("backups/db.dump", args[len(args)-1])
+}
+
+func TestBackupMissingExecutable(t *testing.T) {
+	r := require.New(t)
+
+	d := PgDump{Path: "/nonexistent/pg_dump", RestorePath: "/nonexistent/pg_restore"}
+
+	err := d.Backup("postgres://u:p@localhost:5432/db", "", "db.dump")
+	r.Error(err)
+	r.Contains(err.Error(), "/nonexistent/pg_dump")
+
+	err = d.Restore("postgres://u:p@localhost:5432/db", "db.dump")
+	r.Error(err)
+	r.Contains(err.Error(), "/nonexistent/pg_restore")
+}
@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,13 @@ import (
 func (fs *ImplFilesystem) CreateMigrationFile(name string, location string) error {
 	filename := filepath.Join(location, name)
 
+	if location != "" {
+		err := fs.Fs.MkdirAll(location, 0777)
+		if err != nil {
+			return fmt.Errorf("filesystem error: unable to create directory for migration file %w", err)
+		}
+	}
+
 	_, err := fs.Fs.Create(filename)
 	if err != nil {
 		return fmt.Errorf("filesystem error: unable to create migration file %w", err)
@@ -27,31 +35,21 @@ func (fs *ImplFilesystem) CreateMigrationFile(name string, location string) erro
 	return nil
 }
 
-// DeleteMigrationFiles - removed given migration files from FS
-func (fs *ImplFilesystem) deleteMigrationFiles(list MigrationFileList) error {
-	for _, file := range list {
-		config, err := fs.LoadConfig()
+// WriteMigrationFile - creates a new file in path directory with given content
+func (fs *ImplFilesystem) WriteMigrationFile(name string, location string, content string) error {
+	if location != "" {
+		err := fs.Fs.MkdirAll(location, 0777)
 		if err != nil {
-			return err
-		}
-
-		up := filepath.Join(config.Path, file.Up)
-		err = fs.Fs.Remove(up)
-		if err != nil {
-			return err
-		}
-
-		down := filepath.Join(config.Path, file.Down)
-		err = fs.Fs.Remove(down)
-		if err != nil {
-			return err
+			return fmt.Errorf("filesystem error: unable to create directory for migration file %w", err)
 		}
 	}
 
-	return nil
+	return fs.writeFile([]string{content}, name, Config{Path: location})
 }
 
-// Squash squashes files from given list into one up migration and one down migration
+// Squash squashes files from given list into one up migration and one down migration.
+// Original files are moved into archive directory so the squash can be reverted later.
+// In case of an error all changes on filesystem are rolled back.
 func (fs *ImplFilesystem) Squash(files MigrationFileList) (err error) {
 	if len(files) == 0 {
 		err = fmt.Errorf("filesystem error: No files to squash")
@@ -63,13 +61,82 @@ func (fs *ImplFilesystem) Squash(files MigrationFileList) (err error) {
 		return err
 	}
 
+	up, down, err := fs.getSquashContent(files, config)
+	if err != nil {
+		return err
+	}
+
+	archive := config.GetSquashArchive(files[len(files)-1].Timestamp)
+
+	exists, err := afero.Exists(fs.Fs, archive)
+	if err != nil {
+		return fmt.Errorf("filesystem error: unable to check squash archive %w", err)
+	}
+
+	if exists {
+		return fmt.Errorf("filesystem error: squash archive %s already exists", archive)
+	}
+
+	err = fs.Fs.MkdirAll(archive, 0777)
+	if err != nil {
+		return fmt.Errorf("filesystem error: unable to create squash archive %w", err)
+	}
+
+	tr := transaction{fs: fs.Fs}
+	defer func() {
+		if err != nil {
+			tr.rollback()
+			_ = fs.Fs.RemoveAll(archive)
+		}
+	}()
+
+	// Originals are moved first as squashed files might reuse the name of the last one
+	for _, file := range files {
+		for _, name := range []string{file.Up, file.Down} {
+			err = tr.move(filepath.Join(config.Path, name), filepath.Join(archive, name))
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	upName, downName := files.SquashedFileNames()
+	err = fs.writeFile([]string{up}, upName, config)
+	tr.created(filepath.Join(config.Path, upName))
+	if err != nil {
+		return
+	}
+
+	err = fs.writeFile([]string{down}, downName, config)
+	tr.created(filepath.Join(config.Path, downName))
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+// GetSquashContent returns content of up and down migrations that squashing given files produces
+func (fs *ImplFilesystem) GetSquashContent(files MigrationFileList) (string, string, error) {
+	config, err := fs.LoadConfig()
+	if err != nil {
+		return "", "", err
+	}
+
+	return fs.getSquashContent(files, config)
+}
+
+func (fs *ImplFilesystem) getSquashContent(files MigrationFileList, config Config) (string, string, error) {
+	// Squashed files keep templates so they are rendered at execution time
+	config.Template = false
+
 	up := make([]string, 0, len(files))
 	down := make([]string, 0, len(files))
 
 	for _, file := range files {
 		upContent, err := fs.ReadMigrationContent(file, DirectionUp, config)
 		if err != nil {
-			return err
+			return "", "", err
 		}
 
 		upComment := fmt.Sprintf("\n-- migration %d UP\n", file.Timestamp)
@@ -77,34 +144,75 @@ func (fs *ImplFilesystem) Squash(files MigrationFileList) (err error) {
 
 		downContent, err := fs.ReadMigrationContent(file, DirectionDown, config)
 		if err != nil {
-			return err
+			return "", "", err
 		}
 
 		downComment := fmt.Sprintf("\n-- migration %d DOWN\n", file.Timestamp)
 		down = append(down, fmt.Sprintf("%s%s%s", downComment, downContent, "\n"))
 	}
 
-	lastTs := files[len(files)-1]
-	upName := fmt.Sprintf("mig_%d_%s_up.sql", lastTs.Timestamp, "squashed")
-	err = fs.writeFile(up, upName, config)
-	if err != nil {
-		return
-	}
-
 	// Reverse down migrations
 	for i := 0; i < len(down)/2; i++ {
 		j := len(down) - i - 1
 		down[i], down[j] = down[j], down[i]
 	}
 
-	downName := fmt.Sprintf("mig_%d_%s_down.sql", lastTs.Timestamp, "squashed")
-	err = fs.writeFile(down, downName, config)
+	return strings.Join(up, ""), strings.Join(down, ""), nil
+}
+
+// RestoreSquash reverts squash of migrations ending with given timestamp by moving
+// archived original files back into workspace and removing squashed files.
+// Returns the list of restored migrations.
+func (fs *ImplFilesystem) RestoreSquash(ts int64) (restored MigrationFileList, err error) {
+	config, err := fs.LoadConfig()
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	archive := config.GetSquashArchive(ts)
+
+	restored, err = fs.readMigrationFiles(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(restored) == 0 {
+		return nil, fmt.Errorf("filesystem error: squash archive %s contains no migrations", archive)
+	}
+
+	upPath, downPath, err := fs.getMigrationsForTimestamp(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := transaction{fs: fs.Fs}
+	defer func() {
+		if err != nil {
+			tr.rollback()
+			restored = nil
+		}
+	}()
+
+	// Squashed files are kept aside until originals are back in place
+	for _, squashed := range []string{upPath, downPath} {
+		err = tr.move(squashed, filepath.Join(archive, filepath.Base(squashed)+squashedSuffix))
+		if err != nil {
+			return
+		}
+	}
+
+	for _, file := range restored {
+		for _, name := range []string{file.Up, file.Down} {
+			err = tr.move(filepath.Join(archive, name), filepath.Join(config.Path, name))
+			if err != nil {
+				return
+			}
+		}
 	}
 
-	err = fs.deleteMigrationFiles(files)
+	err = fs.Fs.RemoveAll(archive)
 	if err != nil {
+		err = fmt.Errorf("filesystem error: unable to remove squash archive %w", err)
 		return
 	}
 
@@ -167,7 +275,8 @@ func (fs *ImplFilesystem) getMigrationsForTimestamp(ts int64) (up string, down s
 	return "", "", fmt.Errorf("filesystem error: unable to find migrations for %d", ts)
 }
 
-// ReadMigrationContent for specified migration file reads content as a string
+// ReadMigrationContent for specified migration file reads content as a string.
+// Include directives (\i and \ir) are replaced with content of included files.
 func (fs *ImplFilesystem) ReadMigrationContent(file MigrationFile, direction Direction, config Config) (string, error) {
 	path := file.GetFileName(config, direction)
 
@@ -176,7 +285,30 @@ func (fs *ImplFilesystem) ReadMigrationContent(file MigrationFile, direction Dir
 		return "", fmt.Errorf("filesystem error: unable to read migration file content %w", err)
 	}
 
-	return string(content), nil
+	expanded, err := fs.expandIncludes(path, string(content), config, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return RenderTemplate(filepath.Base(path), expanded, config)
+}
+
+// ReadSeedContent reads content of seed file the same way as ReadMigrationContent,
+// with includes expanded and rendered as template when templating is enabled.
+func (fs *ImplFilesystem) ReadSeedContent(file SeedFile, config Config) (string, error) {
+	path := filepath.Join(config.GetSeedsDir(), file.Name)
+
+	content, err := afero.ReadFile(fs.Fs, path)
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to read seed file content %w", err)
+	}
+
+	expanded, err := fs.expandIncludes(path, string(content), config, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return RenderTemplate(file.Name, expanded, config)
 }
 
 // GetFileTimestamps - gets the list of migrations that are between two arguments.
@@ -188,9 +320,27 @@ func (fs *ImplFilesystem) GetFileTimestamps(from time.Time, to time.Time) (Migra
 		return nil, err
 	}
 
-	files, err := afero.ReadDir(fs.Fs, config.Path)
+	all, err := fs.readMigrationFiles(config.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(MigrationFileList, 0, len(all))
+
+	for _, v := range all {
+		if v.Timestamp > from.Unix() && v.Timestamp <= to.Unix() {
+			result = append(result, v)
+		}
+	}
+
+	return result, nil
+}
+
+// readMigrationFiles - returns sorted list of all migrations found in given directory
+func (fs *ImplFilesystem) readMigrationFiles(dir string) (MigrationFileList, error) {
+	files, err := afero.ReadDir(fs.Fs, dir)
 	if err != nil {
-		return nil, fmt.Errorf("filesystem error: unable to read config file directory %w", err)
+		return nil, fmt.Errorf("filesystem error: unable to read migrations directory %w", err)
 	}
 
 	upPattern := regexp.MustCompile("^mig_([0-9]+).*_up.sql$")
@@ -223,9 +373,99 @@ func (fs *ImplFilesystem) GetFileTimestamps(from time.Time, to time.Time) (Migra
 	result := make(MigrationFileList, 0, len(resultMap))
 
 	for _, v := range resultMap {
-		if v.Timestamp > from.Unix() && v.Timestamp <= to.Unix() {
-			result = append(result, v)
+		result = append(result, v)
+	}
+
+	sort.Sort(result)
+
+	return result, nil
+}
+
+// GetRepeatableMigrations - returns repeatable migrations (rep_<name>.sql files)
+// from workspace sorted by file name
+func (fs *ImplFilesystem) GetRepeatableMigrations() (RepeatableFileList, error) {
+	config, err := fs.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := afero.ReadDir(fs.Fs, config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to read migrations directory %w", err)
+	}
+
+	pattern := regexp.MustCompile("^rep_.+\\.sql$")
+
+	result := make(RepeatableFileList, 0)
+
+	for _, file := range files {
+		if file.IsDir() || !pattern.MatchString(file.Name()) {
+			continue
+		}
+
+		content, err := afero.ReadFile(fs.Fs, filepath.Join(config.Path, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("filesystem error: unable to read repeatable migration content %w", err)
+		}
+
+		result = append(result, RepeatableFile{Name: file.Name(), Content: string(content)})
+	}
+
+	sort.Sort(result)
+
+	return result, nil
+}
+
+// GetSeeds - returns seed files (seed_<timestamp>[_name].sql) from seeds directory
+// sorted by timestamp. Missing seeds directory is treated as empty.
+func (fs *ImplFilesystem) GetSeeds() (SeedFileList, error) {
+	config, err := fs.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := config.GetSeedsDir()
+
+	exists, err := afero.DirExists(fs.Fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to check seeds directory %w", err)
+	}
+
+	if !exists {
+		return SeedFileList{}, nil
+	}
+
+	files, err := afero.ReadDir(fs.Fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to read seeds directory %w", err)
+	}
+
+	pattern := regexp.MustCompile("^seed_([0-9]+).*\\.sql$")
+
+	result := make(SeedFileList, 0, len(files))
+
+	for _, file := range files {
+		submatches := pattern.FindStringSubmatch(file.Name())
+		if file.IsDir() || submatches == nil {
+			continue
+		}
+
+		ts, err := strconv.ParseInt(submatches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("filesystem error: invalid seed file name %s %w", file.Name(), err)
 		}
+
+		content, err := afero.ReadFile(fs.Fs, filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("filesystem error: unable to read seed file content %w", err)
+		}
+
+		result = append(result, SeedFile{
+			Timestamp:    ts,
+			Name:         file.Name(),
+			Content:      string(content),
+			Environments: parseSeedEnvironments(string(content)),
+		})
 	}
 
 	sort.Sort(result)
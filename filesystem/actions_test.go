@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -229,6 +230,7 @@ func TestSquash(t *testing.T) {
 		upContent      string
 		downFilename   string
 		downContent    string
+		archive        string
 	}{
 		{
 			name: "successfully squashes files all files",
@@ -243,6 +245,7 @@ func TestSquash(t *testing.T) {
 			upContent:      "\n-- migration 1 UP\nup mig 1\n\n-- migration 2 UP\nup mig 2\n\n-- migration 3 UP\nup mig 3\n",
 			downFilename:   "mig_3_squashed_down.sql",
 			downContent:    "\n-- migration 3 DOWN\ndown mig 3\n\n-- migration 2 DOWN\ndown mig 2\n\n-- migration 1 DOWN\ndown mig 1\n",
+			archive:        "archive/mig_3_squashed",
 		},
 		{
 			name: "successfully squashes first two files",
@@ -256,6 +259,7 @@ func TestSquash(t *testing.T) {
 			upContent:      "\n-- migration 1 UP\nup mig 1\n\n-- migration 2 UP\nup mig 2\n",
 			downFilename:   "mig_2_squashed_down.sql",
 			downContent:    "\n-- migration 2 DOWN\ndown mig 2\n\n-- migration 1 DOWN\ndown mig 1\n",
+			archive:        "archive/mig_2_squashed",
 		},
 		{
 			name:           "returns error",
@@ -287,6 +291,9 @@ func TestSquash(t *testing.T) {
 			for _, f := range test.deletedFiles {
 				exists, _ := afero.Exists(fs, f)
 				r.False(exists)
+
+				archived, _ := afero.Exists(fs, filepath.Join(test.archive, f))
+				r.True(archived)
 			}
 
 			for _, f := range test.remainingFiles {
@@ -312,3 +319,147 @@ func TestSquash(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteMigrationFile(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	err := fsystem.WriteMigrationFile("mig_1_baseline_up.sql", "workspace", "create table t();")
+	r.NoError(err)
+
+	content, err := afero.ReadFile(fs, "workspace/mig_1_baseline_up.sql")
+	r.NoError(err)
+	r.Equal("create table t();", string(content))
+}
+
+func TestSquashRollback(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, "mig_1_up.sql", []byte("up mig 1"), 0666)
+	afero.WriteFile(fs, "mig_1_down.sql", []byte("down mig 1"), 0666)
+	afero.WriteFile(fs, "mig_2_up.sql", []byte("up mig 2"), 0666)
+	afero.WriteFile(fs, configFileName, []byte(validContent), 0666)
+
+	// Down file of second migration is missing so reading it fails
+	files := MigrationFileList{
+		MigrationFile{Timestamp: 1, Up: "mig_1_up.sql", Down: "mig_1_down.sql"},
+		MigrationFile{Timestamp: 2, Up: "mig_2_up.sql", Down: "mig_2_down.sql"},
+	}
+	r.Error(fsystem.Squash(files))
+
+	// Archive already exists
+	fs.MkdirAll("archive/mig_2_squashed", 0777)
+	afero.WriteFile(fs, "mig_2_down.sql", []byte("down mig 2"), 0666)
+	r.Error(fsystem.Squash(files))
+
+	for _, f := range []string{"mig_1_up.sql", "mig_1_down.sql", "mig_2_up.sql", "mig_2_down.sql"} {
+		exists, _ := afero.Exists(fs, f)
+		r.True(exists, "original file %s should stay in workspace", f)
+	}
+
+	exists, _ := afero.Exists(fs, "mig_2_squashed_up.sql")
+	r.False(exists)
+}
+
+func TestRestoreSquash(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, "mig_1_up.sql", []byte("up mig 1"), 0666)
+	afero.WriteFile(fs, "mig_1_down.sql", []byte("down mig 1"), 0666)
+	afero.WriteFile(fs, "mig_2_up.sql", []byte("up mig 2"), 0666)
+	afero.WriteFile(fs, "mig_2_down.sql", []byte("down mig 2"), 0666)
+	afero.WriteFile(fs, configFileName, []byte(validContent), 0666)
+
+	files := MigrationFileList{
+		MigrationFile{Timestamp: 1, Up: "mig_1_up.sql", Down: "mig_1_down.sql"},
+		MigrationFile{Timestamp: 2, Up: "mig_2_up.sql", Down: "mig_2_down.sql"},
+	}
+	r.NoError(fsystem.Squash(files))
+
+	restored, err := fsystem.RestoreSquash(2)
+	r.NoError(err)
+	r.Equal(files, restored)
+
+	for _, f := range []string{"mig_1_up.sql", "mig_1_down.sql", "mig_2_up.sql", "mig_2_down.sql"} {
+		content, err := afero.ReadFile(fs, f)
+		r.NoError(err)
+		r.NotEmpty(content)
+	}
+
+	for _, f := range []string{"mig_2_squashed_up.sql", "mig_2_squashed_down.sql", "archive/mig_2_squashed"} {
+		exists, _ := afero.Exists(fs, f)
+		r.False(exists, "%s should be removed", f)
+	}
+
+	_, err = fsystem.RestoreSquash(2)
+	r.Error(err, "archive no longer exists")
+}
+
+func TestGetRepeatableMigrations(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, configFileName, []byte(validContent), 0666)
+	afero.WriteFile(fs, "rep_views.sql", []byte("create or replace view v as select 1;"), 0666)
+	afero.WriteFile(fs, "rep_functions.sql", []byte("create or replace function f() returns int as 'select 1' language sql;"), 0666)
+	afero.WriteFile(fs, "rep_notes.txt", []byte("not a migration"), 0666)
+	afero.WriteFile(fs, "mig_1_up.sql", []byte("up"), 0666)
+
+	res, err := fsystem.GetRepeatableMigrations()
+	r.NoError(err)
+	r.Len(res, 2)
+	r.Equal("rep_functions.sql", res[0].Name)
+	r.Equal("rep_views.sql", res[1].Name)
+	r.Equal("create or replace view v as select 1;", res[1].Content)
+}
+
+func TestGetSeeds(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, configFileName, []byte(validContent), 0666)
+
+	seeds, err := fsystem.GetSeeds()
+	r.NoError(err)
+	r.Empty(seeds, "missing seeds directory means no seeds")
+
+	afero.WriteFile(fs, "seeds/seed_20_countries.sql", []byte("-- pg-mig:env dev, test\ninsert into countries values ('RS');"), 0666)
+	afero.WriteFile(fs, "seeds/seed_10.sql", []byte("insert into roles values ('admin');"), 0666)
+	afero.WriteFile(fs, "seeds/readme.md", []byte("not a seed"), 0666)
+
+	seeds, err = fsystem.GetSeeds()
+	r.NoError(err)
+	r.Len(seeds, 2)
+
+	r.Equal(int64(10), seeds[0].Timestamp)
+	r.Equal("seed_10.sql", seeds[0].Name)
+	r.Empty(seeds[0].Environments)
+
+	r.Equal(int64(20), seeds[1].Timestamp)
+	r.Equal([]string{"dev", "test"}, seeds[1].Environments)
+}
+
+func TestReadSeedContent(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, "mig/seeds/seed_10_roles.sql", []byte("\\ir data/roles.sql\ninsert into users values ('{{.user}}');\n"), 0666)
+	afero.WriteFile(fs, "mig/seeds/data/roles.sql", []byte("insert into roles values ('{{.role}}');\n"), 0666)
+
+	config := Config{Path: "mig", Template: true, Vars: map[string]string{"role": "admin"}, CliVars: map[string]string{"user": "demo"}}
+
+	content, err := fsystem.ReadSeedContent(SeedFile{Timestamp: 10, Name: "seed_10_roles.sql"}, config)
+	r.NoError(err)
+	r.Equal("insert into roles values ('admin');\ninsert into users values ('demo');\n", content)
+
+	_, err = fsystem.ReadSeedContent(SeedFile{Timestamp: 20, Name: "seed_20_missing.sql"}, config)
+	r.Error(err)
+}
@@ -0,0 +1,88 @@
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// extension of backup files written by pg_dump in custom format
+const backupExtension = ".dump"
+
+// backupSuffixRegex matches timestamp that PrepareBackup appends to name of the backup
+var backupSuffixRegex = regexp.MustCompile(`^_\d{8}T\d{6}Z$`)
+
+// PrepareBackup creates backup directory and returns location of a new timestamped
+// backup file for given name (usually database or schema name)
+func (fs *ImplFilesystem) PrepareBackup(config Config, name string) (string, error) {
+	dir := config.GetBackupDir()
+
+	err := fs.Fs.MkdirAll(dir, 0777)
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to create backup directory %s %w", dir, err)
+	}
+
+	fileName := fmt.Sprintf("%s_%s%s", name, fs.GetNow().UTC().Format("20060102T150405Z"), backupExtension)
+
+	return filepath.Join(dir, fileName), nil
+}
+
+// GetBackups returns locations of existing backup files sorted from the oldest to the newest
+func (fs *ImplFilesystem) GetBackups(config Config) ([]string, error) {
+	dir := config.GetBackupDir()
+
+	exists, err := afero.DirExists(fs.Fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to check if backup directory exists %w", err)
+	}
+
+	backups := make([]string, 0, 10)
+	if !exists {
+		return backups, nil
+	}
+
+	files, err := afero.ReadDir(fs.Fs, dir)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to read backup directory %w", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), backupExtension) {
+			continue
+		}
+
+		backups = append(backups, filepath.Join(dir, file.Name()))
+	}
+
+	// Timestamp is the last part of the name so the newest backups go last
+	sort.Slice(backups, func(i, j int) bool {
+		return backupTime(backups[i]) < backupTime(backups[j])
+	})
+
+	return backups, nil
+}
+
+func backupTime(location string) string {
+	name := strings.TrimSuffix(filepath.Base(location), backupExtension)
+
+	return name[strings.LastIndex(name, "_")+1:]
+}
+
+// IsBackupOf returns whether backup at given location has been prepared with PrepareBackup for given name
+func IsBackupOf(location string, name string) bool {
+	base := filepath.Base(location)
+	if !strings.HasSuffix(base, backupExtension) {
+		return false
+	}
+
+	base = strings.TrimSuffix(base, backupExtension)
+	if !strings.HasPrefix(base, name) {
+		return false
+	}
+
+	return backupSuffixRegex.MatchString(base[len(name):])
+}
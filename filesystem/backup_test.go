@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareBackup(t *testing.T) {
+	r := require.New(t)
+
+	now, _ := time.Parse(time.RFC3339, "2020-10-20T12:30:05+02:00")
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs, GetNow: func() time.Time { return now }}
+
+	location, err := fsystem.PrepareBackup(Config{Path: "mig"}, "shop_tenant_a")
+	r.NoError(err)
+	r.Equal("mig/backups/shop_tenant_a_20201020T103005Z.dump", location)
+
+	exists, _ := afero.DirExists(fs, "mig/backups")
+	r.True(exists, "backup directory should be created")
+
+	location, err = fsystem.PrepareBackup(Config{Path: "mig", BackupDir: "/var/backups"}, "shop")
+	r.NoError(err)
+	r.Equal("/var/backups/shop_20201020T103005Z.dump", location)
+}
+
+func TestGetBackups(t *testing.T) {
+	r := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+	config := Config{Path: "mig"}
+
+	backups, err := fsystem.GetBackups(config)
+	r.NoError(err)
+	r.Empty(backups)
+
+	_ = afero.WriteFile(fs, "mig/backups/shop_tenant_b_20201021T100000Z.dump", []byte{}, 0666)
+	_ = afero.WriteFile(fs, "mig/backups/shop_20201020T100000Z.dump", []byte{}, 0666)
+	_ = afero.WriteFile(fs, "mig/backups/shop_tenant_a_20201022T100000Z.dump", []byte{}, 0666)
+	_ = afero.WriteFile(fs, "mig/backups/notes.txt", []byte{}, 0666)
+
+	backups, err = fsystem.GetBackups(config)
+	r.NoError(err)
+	r.Equal([]string{
+		"mig/backups/shop_20201020T100000Z.dump",
+		"mig/backups/shop_tenant_b_20201021T100000Z.dump",
+		"mig/backups/shop_tenant_a_20201022T100000Z.dump",
+	}, backups)
+}
+
+func TestIsBackupOf(t *testing.T) {
+	table := []struct {
+		location string
+		name     string
+		isBackup bool
+	}{
+		{location: "mig/backups/shop_20201020T100000Z.dump", name: "shop", isBackup: true},
+		{location: "mig/backups/shop_tenant_a_20201020T100000Z.dump", name: "shop_tenant_a", isBackup: true},
+		{location: "mig/backups/shop_tenant_a_20201020T100000Z.dump", name: "shop"},
+		{location: "mig/backups/shopping_20201020T100000Z.dump", name: "shop"},
+		{location: "mig/backups/shard_1_shop_20201020T100000Z.dump", name: "shop"},
+		{location: "mig/backups/shop_20201020.dump", name: "shop"},
+		{location: "mig/backups/shop_20201020T100000Z.sql", name: "shop"},
+	}
+
+	for _, test := range table {
+		t.Run(test.location+" "+test.name, func(t *testing.T) {
+			require.Equal(t, test.isBackup, IsBackupOf(test.location, test.name))
+		})
+	}
+}
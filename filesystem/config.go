@@ -5,23 +5,55 @@ import (
 	"errors"
 	"fmt"
 	"path"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/afero"
 )
 
 // Config JSON type for storing database configuration
 type Config struct {
-	DbName      string `json:"db_name"`
-	Path        string `json:"path"`
-	DbURL       string `json:"db_url"`
-	Credentials string `json:"credentials"`
-	Port        int    `json:"port"`
-	SSL         string `json:"ssl_mode"`
-	NoColor     bool   `json:"no_color"`
+	DbName          string            `json:"db_name"`
+	Path            string            `json:"path"`
+	DbURL           string            `json:"db_url"`
+	Credentials     string            `json:"credentials"`
+	Port            int               `json:"port"`
+	SSL             string            `json:"ssl_mode"`
+	NoColor         bool              `json:"no_color"`
+	ArchiveDir      string            `json:"archive_dir,omitempty"`
+	SeedsDir        string            `json:"seeds_dir,omitempty"`
+	Environment     string            `json:"environment,omitempty"`
+	Template        bool              `json:"template,omitempty"`
+	Vars            map[string]string `json:"vars,omitempty"`
+	MetaSchema      string            `json:"meta_schema,omitempty"`
+	MetaTable       string            `json:"meta_table,omitempty"`
+	Schemas         []string          `json:"schemas,omitempty"`
+	SchemasQuery    string            `json:"schemas_query,omitempty"`
+	OnTenantFailure string            `json:"on_tenant_failure,omitempty"`
+	Targets         []Target          `json:"targets,omitempty"`
+	Protected       []string          `json:"protected_environments,omitempty"`
+	Backup          bool              `json:"backup,omitempty"`
+	BackupDir       string            `json:"backup_dir,omitempty"`
+	PgDumpPath      string            `json:"pg_dump_path,omitempty"`
+	PgRestorePath   string            `json:"pg_restore_path,omitempty"`
+	SlowThreshold   string            `json:"slow_threshold,omitempty"`
+	FailOnSlow      bool              `json:"fail_on_slow,omitempty"`
+	ConnectAttempts int               `json:"connect_attempts,omitempty"`
+	ConnectBackoff  string            `json:"connect_backoff,omitempty"`
+	ConnectMaxWait  string            `json:"connect_max_wait,omitempty"`
+	CliVars         map[string]string `json:"-"`
 }
 
 const configFileName = "pgmig.config.json"
 
+const defaultArchiveDir = "archive"
+
+const defaultSeedsDir = "seeds"
+
+const defaultBackupDir = "backups"
+
+const defaultOnTenantFailure = "stop"
+
 // StoreConfig - saves configuration in json file
 func (fs *ImplFilesystem) StoreConfig(config Config) error {
 	afs := &afero.Afero{Fs: fs.Fs}
@@ -103,3 +135,83 @@ func (config *Config) GetConnectionString() (string, error) {
 
 	return connectionString, nil
 }
+
+// GetArchiveDir returns directory where original files of squashed migrations are stored
+func (config *Config) GetArchiveDir() string {
+	if config.ArchiveDir != "" {
+		return config.ArchiveDir
+	}
+
+	return filepath.Join(config.Path, defaultArchiveDir)
+}
+
+// GetSquashArchive returns directory holding original files of squash ending with given timestamp
+func (config *Config) GetSquashArchive(ts int64) string {
+	return filepath.Join(config.GetArchiveDir(), fmt.Sprintf("mig_%d_squashed", ts))
+}
+
+// GetSlowThreshold returns duration after which migration is reported as slow, zero when not set
+func (config *Config) GetSlowThreshold() (time.Duration, error) {
+	return parseDuration("slow_threshold", config.SlowThreshold)
+}
+
+// GetConnectBackoff returns delay after the first failed connection attempt, zero when not set
+func (config *Config) GetConnectBackoff() (time.Duration, error) {
+	return parseDuration("connect_backoff", config.ConnectBackoff)
+}
+
+// GetConnectMaxWait returns total time spent connecting on database, zero when not set
+func (config *Config) GetConnectMaxWait() (time.Duration, error) {
+	return parseDuration("connect_max_wait", config.ConnectMaxWait)
+}
+
+func parseDuration(name string, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("filesystem error: invalid %s %s in config %w", name, value, err)
+	}
+
+	return duration, nil
+}
+
+// GetBackupDir returns directory where database backups are written
+func (config *Config) GetBackupDir() string {
+	if config.BackupDir != "" {
+		return config.BackupDir
+	}
+
+	return filepath.Join(config.Path, defaultBackupDir)
+}
+
+// GetSeedsDir returns directory where seed files are stored
+func (config *Config) GetSeedsDir() string {
+	if config.SeedsDir != "" {
+		return config.SeedsDir
+	}
+
+	return filepath.Join(config.Path, defaultSeedsDir)
+}
+
+// GetOnTenantFailure returns policy applied when migrations fail in one of tenant schemas
+func (config *Config) GetOnTenantFailure() string {
+	if config.OnTenantFailure != "" {
+		return config.OnTenantFailure
+	}
+
+	return defaultOnTenantFailure
+}
+
+// IsProtected returns whether current environment is protected from down migrations
+func (config *Config) IsProtected() bool {
+	for _, env := range config.Protected {
+		if env != "" && env == config.Environment {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,7 @@ package filesystem
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 )
@@ -202,3 +203,56 @@ func TestGetConnectionString(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestGetSquashArchive(t *testing.T) {
+	config := Config{Path: "workspace"}
+	if archive := config.GetSquashArchive(12); archive != "workspace/archive/mig_12_squashed" {
+		t.Logf("Unexpected default archive %s", archive)
+		t.Fail()
+	}
+
+	config.ArchiveDir = "/var/archive"
+	if archive := config.GetSquashArchive(12); archive != "/var/archive/mig_12_squashed" {
+		t.Logf("Unexpected configured archive %s", archive)
+		t.Fail()
+	}
+}
+
+func TestGetSlowThreshold(t *testing.T) {
+	config := Config{}
+	if threshold, err := config.GetSlowThreshold(); err != nil || threshold != 0 {
+		t.Logf("Unexpected default threshold %s %v", threshold, err)
+		t.Fail()
+	}
+
+	config.SlowThreshold = "1m30s"
+	if threshold, err := config.GetSlowThreshold(); err != nil || threshold != 90*time.Second {
+		t.Logf("Unexpected configured threshold %s %v", threshold, err)
+		t.Fail()
+	}
+
+	config.SlowThreshold = "slow"
+	if _, err := config.GetSlowThreshold(); err == nil {
+		t.Log("Expected to get error for invalid threshold")
+		t.Fail()
+	}
+}
+
+func TestGetConnectRetry(t *testing.T) {
+	config := Config{ConnectBackoff: "500ms", ConnectMaxWait: "2m"}
+	if backoff, err := config.GetConnectBackoff(); err != nil || backoff != 500*time.Millisecond {
+		t.Logf("Unexpected configured backoff %s %v", backoff, err)
+		t.Fail()
+	}
+
+	if maxWait, err := config.GetConnectMaxWait(); err != nil || maxWait != 2*time.Minute {
+		t.Logf("Unexpected configured max wait %s %v", maxWait, err)
+		t.Fail()
+	}
+
+	config.ConnectMaxWait = "forever"
+	if _, err := config.GetConnectMaxWait(); err == nil {
+		t.Log("Expected to get error for invalid max wait")
+		t.Fail()
+	}
+}
@@ -0,0 +1,78 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/afero"
+)
+
+// Names of hook files (without .sql extension) looked up in migrations directory
+const (
+	HookBeforeAll  = "before_all"
+	HookAfterAll   = "after_all"
+	HookBeforeEach = "before_each"
+	HookAfterEach  = "after_each"
+)
+
+var hookNames = []string{HookBeforeAll, HookAfterAll, HookBeforeEach, HookAfterEach}
+
+// GetHooks returns content of existing hook files keyed by hook name
+func (fs *ImplFilesystem) GetHooks() (map[string]string, error) {
+	config, err := fs.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+
+	for _, name := range hookNames {
+		location := filepath.Join(config.Path, name+".sql")
+
+		exists, err := afero.Exists(fs.Fs, location)
+		if err != nil {
+			return nil, fmt.Errorf("filesystem error: unable to check if hook %s exists %w", name, err)
+		}
+
+		if !exists {
+			continue
+		}
+
+		content, err := afero.ReadFile(fs.Fs, location)
+		if err != nil {
+			return nil, fmt.Errorf("filesystem error: unable to read hook %s %w", name, err)
+		}
+
+		result[name] = string(content)
+	}
+
+	return result, nil
+}
+
+// RenderHook renders hook content as Go text/template. Besides template variables
+// it has access to metadata of the current migration (name, timestamp and direction).
+func RenderHook(name string, content string, config Config, metadata map[string]string) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"env": os.Getenv,
+	}).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to parse hook %s %w", name, err)
+	}
+
+	data := config.GetTemplateVars()
+	for k, v := range metadata {
+		data[k] = v
+	}
+
+	var builder strings.Builder
+
+	err = tmpl.Execute(&builder, data)
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to render hook %s %w", name, err)
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,43 @@
+package filesystem
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestGetHooks(t *testing.T) {
+	r := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(`{"path": "/migrations"}`), os.ModePerm)
+	_ = afero.WriteFile(fs, "/migrations/before_all.sql", []byte("select 1;"), os.ModePerm)
+	_ = afero.WriteFile(fs, "/migrations/after_each.sql", []byte("notify migrations, '{{.name}}';"), os.ModePerm)
+	_ = afero.WriteFile(fs, "/migrations/after_other.sql", []byte("select 2;"), os.ModePerm)
+
+	impl := ImplFilesystem{Fs: fs}
+
+	hooks, err := impl.GetHooks()
+	r.NoError(err)
+	r.Equal(map[string]string{
+		HookBeforeAll: "select 1;",
+		HookAfterEach: "notify migrations, '{{.name}}';",
+	}, hooks)
+}
+
+func TestRenderHook(t *testing.T) {
+	r := require.New(t)
+
+	config := Config{Vars: map[string]string{"channel": "migrations", "name": "overridden"}}
+
+	res, err := RenderHook(HookAfterEach, "notify {{.channel}}, '{{.direction}} {{.name}}';", config, map[string]string{
+		"name":      "mig_1_up.sql",
+		"direction": "up",
+	})
+	r.NoError(err)
+	r.Equal("notify migrations, 'up mig_1_up.sql';", res)
+
+	_, err = RenderHook(HookBeforeAll, "select '{{.name}}';", Config{}, nil)
+	r.Error(err)
+}
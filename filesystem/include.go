@@ -0,0 +1,96 @@
+package filesystem
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// matches psql include directives: \i (relative to workspace) and \ir (relative to including file)
+var includeRegex = regexp.MustCompile(`^\s*\\(i|ir|include|include_relative)\s+(?:'([^']+)'|(\S+))\s*$`)
+
+// matches line that ends COPY ... FROM stdin statement, data rows follow it
+var copyFromStdinLineRegex = regexp.MustCompile(`(?i)\bfrom\s+stdin\b.*;\s*$`)
+
+// expandIncludes replaces include directives in content of file on path with content
+// of included files. Lines inside COPY data blocks are left intact.
+func (fs *ImplFilesystem) expandIncludes(path string, content string, config Config, visited []string) (string, error) {
+	for _, v := range visited {
+		if v == path {
+			return "", fmt.Errorf("filesystem error: circular include of %s", path)
+		}
+	}
+	visited = append(visited, path)
+
+	lines := strings.SplitAfter(content, "\n")
+	var builder strings.Builder
+	inCopyData := false
+
+	for _, line := range lines {
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if inCopyData {
+			inCopyData = trimmed != "\\."
+			builder.WriteString(line)
+			continue
+		}
+
+		submatches := includeRegex.FindStringSubmatch(trimmed)
+		if submatches == nil {
+			inCopyData = copyFromStdinLineRegex.MatchString(trimmed)
+			builder.WriteString(line)
+			continue
+		}
+
+		included, err := fs.resolveInclude(path, submatches, config)
+		if err != nil {
+			return "", err
+		}
+
+		includedContent, err := afero.ReadFile(fs.Fs, included)
+		if err != nil {
+			return "", fmt.Errorf("filesystem error: unable to read included file %s %w", included, err)
+		}
+
+		expanded, err := fs.expandIncludes(included, string(includedContent), config, visited)
+		if err != nil {
+			return "", err
+		}
+
+		builder.WriteString(expanded)
+		if !strings.HasSuffix(expanded, "\n") && strings.HasSuffix(line, "\n") {
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// resolveInclude returns location of file referenced by include directive. Included
+// files have to be inside of the workspace.
+func (fs *ImplFilesystem) resolveInclude(path string, submatches []string, config Config) (string, error) {
+	name := submatches[2]
+	if name == "" {
+		name = submatches[3]
+	}
+
+	base := config.Path
+	if submatches[1] == "ir" || submatches[1] == "include_relative" {
+		base = filepath.Dir(path)
+	}
+
+	location := name
+	if !filepath.IsAbs(name) {
+		location = filepath.Join(base, name)
+	}
+
+	relative, err := filepath.Rel(filepath.Clean(config.Path), location)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("filesystem error: included file %s is outside of workspace", name)
+	}
+
+	return location, nil
+}
@@ -0,0 +1,102 @@
+package filesystem
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMigrationContentIncludes(t *testing.T) {
+	file := MigrationFile{Up: "mig_123_up.sql", Down: "mig_123_down.sql", Timestamp: 123}
+
+	table := []struct {
+		name     string
+		files    map[string]string
+		template bool
+		result   string
+		errorMsg string
+	}{
+		{
+			name: "include relative to workspace",
+			files: map[string]string{
+				"mig/mig_123_up.sql":    "create table a();\n\\i data/a.sql\nselect 1;\n",
+				"mig/data/a.sql":        "insert into a default values;\n\\ir nested/b.sql\n",
+				"mig/data/nested/b.sql": "insert into a default values;",
+			},
+			result: "create table a();\ninsert into a default values;\ninsert into a default values;\nselect 1;\n",
+		},
+		{
+			name: "quoted include name",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "\\include 'data/a b.sql'\n",
+				"mig/data/a b.sql":   "select 1;\n",
+			},
+			result: "select 1;\n",
+		},
+		{
+			name: "directives inside copy data are not expanded",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "COPY public.a (v) FROM stdin;\n\\i x.sql\n\\.\n",
+			},
+			result: "COPY public.a (v) FROM stdin;\n\\i x.sql\n\\.\n",
+		},
+		{
+			name: "included files are templated",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "\\i a.sql\n",
+				"mig/a.sql":          "create schema {{.schema}};",
+			},
+			template: true,
+			result:   "create schema tenant;\n",
+		},
+		{
+			name: "missing included file",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "\\i missing.sql\n",
+			},
+			errorMsg: "filesystem error: unable to read included file mig/missing.sql",
+		},
+		{
+			name: "circular include",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "\\i a.sql\n",
+				"mig/a.sql":          "\\i b.sql\n",
+				"mig/b.sql":          "\\ir a.sql\n",
+			},
+			errorMsg: "filesystem error: circular include of mig/a.sql",
+		},
+		{
+			name: "include outside of workspace",
+			files: map[string]string{
+				"mig/mig_123_up.sql": "\\i ../secret.sql\n",
+				"secret.sql":         "select 1;",
+			},
+			errorMsg: "filesystem error: included file ../secret.sql is outside of workspace",
+		},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			for name, content := range row.files {
+				r.NoError(afero.WriteFile(fs, name, []byte(content), 0666))
+			}
+
+			config := Config{Path: "mig", Template: row.template, Vars: map[string]string{"schema": "tenant"}}
+			fsystem := &ImplFilesystem{Fs: fs}
+
+			content, err := fsystem.ReadMigrationContent(file, DirectionUp, config)
+			if row.errorMsg != "" {
+				r.Error(err)
+				r.Contains(err.Error(), row.errorMsg)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(row.result, content)
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package filesystem
 
-import "path/filepath"
+import (
+	"fmt"
+	"path/filepath"
+)
 
 type MigrationFile struct {
 	Timestamp int64
@@ -21,6 +24,19 @@ func (mf MigrationFile) GetFileName(config Config, direction Direction) string {
 
 type MigrationFileList []MigrationFile
 
+// SquashedFileNames - returns names of up and down files that squashing the list produces
+func (m MigrationFileList) SquashedFileNames() (up string, down string) {
+	if len(m) == 0 {
+		return
+	}
+
+	last := m[len(m)-1]
+	up = fmt.Sprintf("mig_%d_%s_up.sql", last.Timestamp, "squashed")
+	down = fmt.Sprintf("mig_%d_%s_down.sql", last.Timestamp, "squashed")
+
+	return
+}
+
 func (m MigrationFileList) Len() int           { return len(m) }
 func (m MigrationFileList) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
 func (m MigrationFileList) Less(i, j int) bool { return m[i].Timestamp < m[j].Timestamp }
@@ -23,3 +23,53 @@ func TestMigrationGetFileName(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestSquashedFileNames(t *testing.T) {
+	list := MigrationFileList{
+		MigrationFile{Timestamp: 1, Up: "mig_1_up.sql", Down: "mig_1_down.sql"},
+		MigrationFile{Timestamp: 2, Up: "mig_2_up.sql", Down: "mig_2_down.sql"},
+	}
+
+	up, down := list.SquashedFileNames()
+	if up != "mig_2_squashed_up.sql" || down != "mig_2_squashed_down.sql" {
+		t.Logf("Invalid squashed names: %s %s", up, down)
+		t.Fail()
+	}
+
+	up, down = MigrationFileList{}.SquashedFileNames()
+	if up != "" || down != "" {
+		t.Log("Empty list should not produce names")
+		t.Fail()
+	}
+}
+
+func TestRepeatableChecksum(t *testing.T) {
+	first := RepeatableFile{Name: "rep_a.sql", Content: "select 1;"}
+	second := RepeatableFile{Name: "rep_b.sql", Content: "select 1;"}
+	changed := RepeatableFile{Name: "rep_a.sql", Content: "select 2;"}
+
+	if first.Checksum() != second.Checksum() {
+		t.Log("Checksum should depend only on content")
+		t.Fail()
+	}
+
+	if first.Checksum() == changed.Checksum() {
+		t.Log("Checksum should change with content")
+		t.Fail()
+	}
+}
+
+func TestSeedRunsIn(t *testing.T) {
+	untagged := SeedFile{Name: "seed_1.sql"}
+	tagged := SeedFile{Name: "seed_2.sql", Environments: []string{"dev", "test"}}
+
+	if !untagged.RunsIn("production") || !untagged.RunsIn("") {
+		t.Log("Seed without tags should run in every environment")
+		t.Fail()
+	}
+
+	if !tagged.RunsIn("dev") || tagged.RunsIn("production") || tagged.RunsIn("") {
+		t.Log("Tagged seed should run only in listed environments")
+		t.Fail()
+	}
+}
@@ -0,0 +1,24 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// RepeatableFile repeatable migration that is re-applied whenever its content changes
+type RepeatableFile struct {
+	Name    string
+	Content string
+}
+
+// Checksum - returns hex encoded sha256 of migration content
+func (rf RepeatableFile) Checksum() string {
+	sum := sha256.Sum256([]byte(rf.Content))
+	return hex.EncodeToString(sum[:])
+}
+
+type RepeatableFileList []RepeatableFile
+
+func (r RepeatableFileList) Len() int           { return len(r) }
+func (r RepeatableFileList) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r RepeatableFileList) Less(i, j int) bool { return r[i].Name < r[j].Name }
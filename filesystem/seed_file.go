@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"strings"
+)
+
+// comment prefix used in seed files to restrict environments they run in
+const seedEnvTag = "-- pg-mig:env"
+
+// SeedFile data migration that is applied only once and only in matching environments
+type SeedFile struct {
+	Timestamp    int64
+	Name         string
+	Content      string
+	Environments []string
+}
+
+// RunsIn - returns whether seed is allowed to run in given environment.
+// Seeds without environment tags run everywhere.
+func (sf SeedFile) RunsIn(environment string) bool {
+	if len(sf.Environments) == 0 {
+		return true
+	}
+
+	for _, env := range sf.Environments {
+		if env == environment {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseSeedEnvironments - reads environment tags in form `-- pg-mig:env dev,test`
+func parseSeedEnvironments(content string) []string {
+	result := make([]string, 0)
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, seedEnvTag) {
+			continue
+		}
+
+		for _, env := range strings.Split(strings.TrimPrefix(line, seedEnvTag), ",") {
+			env = strings.TrimSpace(env)
+			if env != "" {
+				result = append(result, env)
+			}
+		}
+	}
+
+	return result
+}
+
+type SeedFileList []SeedFile
+
+func (s SeedFileList) Len() int           { return len(s) }
+func (s SeedFileList) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s SeedFileList) Less(i, j int) bool { return s[i].Timestamp < s[j].Timestamp }
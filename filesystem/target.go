@@ -0,0 +1,68 @@
+package filesystem
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/afero"
+)
+
+// Target database migrations can be run against. Empty fields are inherited from config.
+type Target struct {
+	Name        string `json:"name"`
+	DbURL       string `json:"db_url,omitempty"`
+	DbName      string `json:"db_name,omitempty"`
+	Credentials string `json:"credentials,omitempty"`
+	Port        int    `json:"port,omitempty"`
+	SSL         string `json:"ssl_mode,omitempty"`
+}
+
+// Apply returns copy of config pointing to target database
+func (target Target) Apply(config Config) Config {
+	if target.DbURL != "" {
+		config.DbURL = target.DbURL
+	}
+
+	if target.DbName != "" {
+		config.DbName = target.DbName
+	}
+
+	if target.Credentials != "" {
+		config.Credentials = target.Credentials
+	}
+
+	if target.Port != 0 {
+		config.Port = target.Port
+	}
+
+	if target.SSL != "" {
+		config.SSL = target.SSL
+	}
+
+	return config
+}
+
+// LoadTargets reads list of targets from JSON file on given path
+func (fs *ImplFilesystem) LoadTargets(path string) ([]Target, error) {
+	afs := &afero.Afero{Fs: fs.Fs}
+
+	data, err := afs.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to read targets file %w", err)
+	}
+
+	targets := make([]Target, 0, 10)
+
+	err = json.Unmarshal(data, &targets)
+	if err != nil {
+		return nil, fmt.Errorf("filesystem error: unable to deserialize targets file %s %w", path, err)
+	}
+
+	for i, target := range targets {
+		if target.Name == "" {
+			return nil, fmt.Errorf("filesystem error: target %d in %s has no name", i, path)
+		}
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,43 @@
+package filesystem
+
+import (
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestTargetApply(t *testing.T) {
+	r := require.New(t)
+
+	config := Config{DbURL: "localhost", DbName: "main", Credentials: "u:p", Port: 5432, SSL: "disable", Path: "/migrations"}
+
+	res := Target{Name: "shard_1", DbURL: "shard1.local", DbName: "shard_1"}.Apply(config)
+
+	r.Equal(Config{DbURL: "shard1.local", DbName: "shard_1", Credentials: "u:p", Port: 5432, SSL: "disable", Path: "/migrations"}, res)
+	r.Equal("localhost", config.DbURL, "original config should not change")
+}
+
+func TestLoadTargets(t *testing.T) {
+	r := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "targets.json", []byte(`[{"name": "shard_1", "db_name": "s1"}, {"name": "shard_2", "port": 5433}]`), os.ModePerm)
+	_ = afero.WriteFile(fs, "unnamed.json", []byte(`[{"db_name": "s1"}]`), os.ModePerm)
+	_ = afero.WriteFile(fs, "invalid.json", []byte(`{`), os.ModePerm)
+
+	impl := ImplFilesystem{Fs: fs}
+
+	targets, err := impl.LoadTargets("targets.json")
+	r.NoError(err)
+	r.Equal([]Target{{Name: "shard_1", DbName: "s1"}, {Name: "shard_2", Port: 5433}}, targets)
+
+	_, err = impl.LoadTargets("unnamed.json")
+	r.Error(err)
+
+	_, err = impl.LoadTargets("invalid.json")
+	r.Error(err)
+
+	_, err = impl.LoadTargets("missing.json")
+	r.Error(err)
+}
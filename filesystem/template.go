@@ -0,0 +1,63 @@
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// prefix of environment variables that are available in migration templates
+const templateEnvPrefix = "PG_MIG_VAR_"
+
+// GetTemplateVars returns variables available in migration templates. Variables
+// from config are overridden by PG_MIG_VAR_<name> environment variables, which are
+// overridden by variables passed on command line.
+func (config *Config) GetTemplateVars() map[string]string {
+	vars := make(map[string]string)
+
+	for k, v := range config.Vars {
+		vars[k] = v
+	}
+
+	for _, entry := range os.Environ() {
+		if !strings.HasPrefix(entry, templateEnvPrefix) {
+			continue
+		}
+
+		pair := strings.SplitN(strings.TrimPrefix(entry, templateEnvPrefix), "=", 2)
+		if len(pair) == 2 && pair[0] != "" {
+			vars[pair[0]] = pair[1]
+		}
+	}
+
+	for k, v := range config.CliVars {
+		vars[k] = v
+	}
+
+	return vars
+}
+
+// RenderTemplate renders migration content as Go text/template when templating
+// is enabled in config. Otherwise content is returned verbatim.
+func RenderTemplate(name string, content string, config Config) (string, error) {
+	if !config.Template {
+		return content, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"env": os.Getenv,
+	}).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to parse migration template %s %w", name, err)
+	}
+
+	var builder strings.Builder
+
+	err = tmpl.Execute(&builder, config.GetTemplateVars())
+	if err != nil {
+		return "", fmt.Errorf("filesystem error: unable to render migration template %s %w", name, err)
+	}
+
+	return builder.String(), nil
+}
@@ -0,0 +1,61 @@
+package filesystem
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	r := require.New(t)
+
+	content := "create schema {{.schema}}; grant usage on schema {{.schema}} to {{.role}};"
+
+	res, err := RenderTemplate("mig_1_up.sql", content, Config{})
+	r.NoError(err)
+	r.Equal(content, res, "content should be verbatim when templating is disabled")
+
+	config := Config{
+		Template: true,
+		Vars:     map[string]string{"schema": "tenant_a", "role": "reader"},
+	}
+
+	res, err = RenderTemplate("mig_1_up.sql", content, config)
+	r.NoError(err)
+	r.Equal("create schema tenant_a; grant usage on schema tenant_a to reader;", res)
+
+	_ = os.Setenv("PG_MIG_VAR_role", "writer")
+	defer os.Unsetenv("PG_MIG_VAR_role")
+
+	res, err = RenderTemplate("mig_1_up.sql", content, config)
+	r.NoError(err)
+	r.Equal("create schema tenant_a; grant usage on schema tenant_a to writer;", res, "environment overrides config")
+
+	config.CliVars = map[string]string{"role": "admin"}
+	res, err = RenderTemplate("mig_1_up.sql", content, config)
+	r.NoError(err)
+	r.Equal("create schema tenant_a; grant usage on schema tenant_a to admin;", res, "command line overrides environment")
+
+	_, err = RenderTemplate("mig_1_up.sql", "select {{.missing}}", config)
+	r.Error(err, "missing variable should return error")
+
+	_, err = RenderTemplate("mig_1_up.sql", "select {{.schema", config)
+	r.Error(err, "invalid template should return error")
+}
+
+func TestReadMigrationContentTemplate(t *testing.T) {
+	r := require.New(t)
+	fs := afero.NewMemMapFs()
+	fsystem := &ImplFilesystem{Fs: fs}
+
+	afero.WriteFile(fs, "mig_1_up.sql", []byte("create table {{.schema}}.users();"), 0666)
+
+	file := MigrationFile{Timestamp: 1, Up: "mig_1_up.sql"}
+	config := Config{Template: true, Vars: map[string]string{"schema": "tenant_b"}}
+
+	content, err := fsystem.ReadMigrationContent(file, DirectionUp, config)
+	r.NoError(err)
+	r.Equal("create table tenant_b.users();", content)
+}
@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+)
+
+// suffix appended to squashed files while they are kept aside during restore
+const squashedSuffix = ".squashed"
+
+type move struct {
+	from string
+	to   string
+}
+
+// transaction keeps track of changes made on filesystem so they can be reverted
+type transaction struct {
+	fs      afero.Fs
+	moves   []move
+	creates []string
+}
+
+func (t *transaction) move(from string, to string) error {
+	err := t.fs.Rename(from, to)
+	if err != nil {
+		return fmt.Errorf("filesystem error: unable to move %s to %s %w", from, to, err)
+	}
+
+	t.moves = append(t.moves, move{from: from, to: to})
+
+	return nil
+}
+
+func (t *transaction) created(path string) {
+	t.creates = append(t.creates, path)
+}
+
+// rollback reverts tracked changes in reverse order. It's a best effort operation.
+func (t *transaction) rollback() {
+	for i := len(t.creates) - 1; i >= 0; i-- {
+		_ = t.fs.Remove(t.creates[i])
+	}
+
+	for i := len(t.moves) - 1; i >= 0; i-- {
+		_ = t.fs.Rename(t.moves[i].to, t.moves[i].from)
+	}
+
+	t.creates = nil
+	t.moves = nil
+}
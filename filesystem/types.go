@@ -34,8 +34,18 @@ func (d Direction) String() string {
 type Filesystem interface {
 	StoreConfig(config Config) error
 	LoadConfig() (Config, error)
+	LoadTargets(string) ([]Target, error)
 	CreateMigrationFile(string, string) error
+	WriteMigrationFile(string, string, string) error
 	ReadMigrationContent(MigrationFile, Direction, Config) (string, error)
+	ReadSeedContent(SeedFile, Config) (string, error)
 	GetFileTimestamps(time.Time, time.Time) (MigrationFileList, error)
+	GetRepeatableMigrations() (RepeatableFileList, error)
+	GetSeeds() (SeedFileList, error)
+	GetHooks() (map[string]string, error)
 	Squash(MigrationFileList) error
+	GetSquashContent(MigrationFileList) (string, string, error)
+	RestoreSquash(int64) (MigrationFileList, error)
+	PrepareBackup(Config, string) (string, error)
+	GetBackups(Config) ([]string, error)
 }
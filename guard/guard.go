@@ -0,0 +1,87 @@
+package guard
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/djordjev/pg-mig/splitter"
+)
+
+// Finding destructive statement found in SQL
+type Finding struct {
+	Statement string
+	Reason    string
+}
+
+var (
+	commentRegex    = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+	literalRegex    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	whitespaceRegex = regexp.MustCompile(`\s+`)
+
+	dropTableRegex  = regexp.MustCompile(`(?i)^drop\s+table\b`)
+	alterTableRegex = regexp.MustCompile(`(?i)^alter\s+table\b`)
+	dropRegex       = regexp.MustCompile(`(?i)\bdrop\s+(\w+)`)
+	truncateRegex   = regexp.MustCompile(`(?i)^truncate\b`)
+	deleteRegex     = regexp.MustCompile(`(?i)^delete\s+from\b`)
+	whereRegex      = regexp.MustCompile(`(?i)\bwhere\b`)
+)
+
+// alter table ... drop <keyword> forms that don't remove data
+var nonDestructiveDrops = map[string]bool{
+	"constraint": true,
+	"default":    true,
+	"not":        true,
+	"identity":   true,
+	"expression": true,
+}
+
+// Scan statically looks for statements that remove data: DROP TABLE, dropping
+// a column, TRUNCATE and DELETE without WHERE clause
+func Scan(sql string) []Finding {
+	findings := make([]Finding, 0)
+
+	for _, statement := range Statements(sql) {
+		reason := check(statement)
+		if reason != "" {
+			findings = append(findings, Finding{Statement: statement, Reason: reason})
+		}
+	}
+
+	return findings
+}
+
+func check(statement string) string {
+	switch {
+	case dropTableRegex.MatchString(statement):
+		return "drops table"
+	case truncateRegex.MatchString(statement):
+		return "truncates table"
+	case deleteRegex.MatchString(statement) && !whereRegex.MatchString(statement):
+		return "deletes all rows without where clause"
+	case alterTableRegex.MatchString(statement):
+		for _, match := range dropRegex.FindAllStringSubmatch(statement, -1) {
+			if !nonDestructiveDrops[strings.ToLower(match[1])] {
+				return "drops column"
+			}
+		}
+	}
+
+	return ""
+}
+
+// Statements returns normalized statements of SQL without comments and string literals
+func Statements(sql string) []string {
+	result := make([]string, 0)
+
+	for _, statement := range splitter.Split(sql) {
+		text := commentRegex.ReplaceAllString(statement.Text, " ")
+		text = literalRegex.ReplaceAllString(text, "''")
+		text = strings.TrimSpace(whitespaceRegex.ReplaceAllString(text, " "))
+
+		if text != "" {
+			result = append(result, text)
+		}
+	}
+
+	return result
+}
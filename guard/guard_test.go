@@ -0,0 +1,63 @@
+package guard
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		name     string
+		sql      string
+		expected []Finding
+	}{
+		{
+			name:     "drop table",
+			sql:      "create table a(); DROP TABLE users;",
+			expected: []Finding{{Statement: "DROP TABLE users", Reason: "drops table"}},
+		},
+		{
+			name:     "drop column",
+			sql:      "alter table users\n  drop column email;\nalter table users drop name",
+			expected: []Finding{{Statement: "alter table users drop column email", Reason: "drops column"}, {Statement: "alter table users drop name", Reason: "drops column"}},
+		},
+		{
+			name:     "alter table drops that keep data",
+			sql:      "alter table users drop constraint users_pk; alter table users alter column a drop not null; alter table users alter column a drop default;",
+			expected: []Finding{},
+		},
+		{
+			name:     "truncate",
+			sql:      "truncate sessions;",
+			expected: []Finding{{Statement: "truncate sessions", Reason: "truncates table"}},
+		},
+		{
+			name:     "delete with and without where",
+			sql:      "delete from sessions where expired; delete from logs;",
+			expected: []Finding{{Statement: "delete from logs", Reason: "deletes all rows without where clause"}},
+		},
+		{
+			name:     "comments and literals are ignored",
+			sql:      "-- drop table users;\n/* truncate logs; */ insert into notes values ('drop table users;');",
+			expected: []Finding{},
+		},
+		{
+			name:     "statements inside function bodies are not checked",
+			sql:      "create function purge() returns void as $$ delete from logs; $$ language sql;",
+			expected: []Finding{},
+		},
+		{
+			name:     "drop index is allowed",
+			sql:      "drop index users_email_idx;",
+			expected: []Finding{},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r.Equal(test.expected, Scan(test.sql))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package lint
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/djordjev/pg-mig/guard"
+)
+
+// Rule pattern known to cause downtime or long locks on Postgres
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+	check       func(statement string, state *fileState) bool
+}
+
+// Finding statement of migration file that violates a rule
+type Finding struct {
+	File      string `json:"file"`
+	Rule      string `json:"rule"`
+	Name      string `json:"name"`
+	Message   string `json:"message"`
+	Statement string `json:"statement"`
+}
+
+// fileState things seen in previous statements of the same file
+type fileState struct {
+	lockTimeout bool
+	validated   map[string]bool
+}
+
+var (
+	ignoreRegex = regexp.MustCompile(`(?mi)^\s*--\s*pg-mig:lint-ignore\s+(.+)$`)
+
+	alterTableRegex     = regexp.MustCompile(`(?i)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?([\w."]+)`)
+	addColumnRegex      = regexp.MustCompile(`(?i)\badd\s+(?:column\s+)?.*\bdefault\s+(.+)`)
+	volatileRegex       = regexp.MustCompile(`(?i)\b(random|clock_timestamp|timeofday|gen_random_uuid|uuid_generate_v1|uuid_generate_v4|nextval)\s*\(`)
+	createIndexRegex    = regexp.MustCompile(`(?i)^create\s+(?:unique\s+)?index\b`)
+	concurrentlyRegex   = regexp.MustCompile(`(?i)\bconcurrently\b`)
+	setNotNullRegex     = regexp.MustCompile(`(?i)\balter\s+(?:column\s+)?[\w"]+\s+set\s+not\s+null\b`)
+	validateRegex       = regexp.MustCompile(`(?i)\bvalidate\s+constraint\b`)
+	typeChangeRegex     = regexp.MustCompile(`(?i)\balter\s+(?:column\s+)?[\w"]+\s+(?:set\s+data\s+)?type\b`)
+	setLockTimeoutRegex = regexp.MustCompile(`(?i)^set\s+(?:local\s+)?lock_timeout\b`)
+)
+
+// Rules all rules checked by linter
+var Rules = []Rule{
+	{
+		ID:          "PGM001",
+		Name:        "volatile-default",
+		Description: "adding a column with a volatile default rewrites the whole table under an exclusive lock",
+		check: func(statement string, _ *fileState) bool {
+			if !alterTableRegex.MatchString(statement) {
+				return false
+			}
+
+			match := addColumnRegex.FindStringSubmatch(statement)
+
+			return match != nil && volatileRegex.MatchString(match[1])
+		},
+	},
+	{
+		ID:          "PGM002",
+		Name:        "index-not-concurrently",
+		Description: "creating an index without CONCURRENTLY blocks writes to the table until it's built",
+		check: func(statement string, _ *fileState) bool {
+			return createIndexRegex.MatchString(statement) && !concurrentlyRegex.MatchString(statement)
+		},
+	},
+	{
+		ID:          "PGM003",
+		Name:        "not-null-without-check",
+		Description: "SET NOT NULL scans the whole table under an exclusive lock unless a validated check constraint proves it first",
+		check: func(statement string, state *fileState) bool {
+			match := alterTableRegex.FindStringSubmatch(statement)
+
+			return match != nil && setNotNullRegex.MatchString(statement) && !state.validated[strings.ToLower(match[1])]
+		},
+	},
+	{
+		ID:          "PGM004",
+		Name:        "column-type-change",
+		Description: "changing type of a column usually rewrites the whole table under an exclusive lock",
+		check: func(statement string, _ *fileState) bool {
+			return alterTableRegex.MatchString(statement) && typeChangeRegex.MatchString(statement)
+		},
+	},
+	{
+		ID:          "PGM005",
+		Name:        "missing-lock-timeout",
+		Description: "ALTER TABLE without lock_timeout can queue behind long transactions and block all queries to the table",
+		check: func(statement string, state *fileState) bool {
+			return alterTableRegex.MatchString(statement) && !state.lockTimeout
+		},
+	},
+}
+
+// Lint checks all statements of migration file against rules except the ones
+// suppressed in the file with "-- pg-mig:lint-ignore PGM001,PGM002" comment
+func Lint(file string, sql string) []Finding {
+	ignored := ignoredRules(sql)
+	state := &fileState{validated: make(map[string]bool)}
+	findings := make([]Finding, 0)
+
+	for _, statement := range guard.Statements(sql) {
+		for _, rule := range Rules {
+			if ignored[rule.ID] || !rule.check(statement, state) {
+				continue
+			}
+
+			findings = append(findings, Finding{
+				File:      file,
+				Rule:      rule.ID,
+				Name:      rule.Name,
+				Message:   rule.Description,
+				Statement: statement,
+			})
+
+			// Missing lock timeout is reported once per file
+			if rule.ID == "PGM005" {
+				state.lockTimeout = true
+			}
+		}
+
+		state.update(statement)
+	}
+
+	return findings
+}
+
+func (state *fileState) update(statement string) {
+	if setLockTimeoutRegex.MatchString(statement) {
+		state.lockTimeout = true
+	}
+
+	match := alterTableRegex.FindStringSubmatch(statement)
+	if match != nil && validateRegex.MatchString(statement) {
+		state.validated[strings.ToLower(match[1])] = true
+	}
+}
+
+func ignoredRules(sql string) map[string]bool {
+	result := make(map[string]bool)
+
+	for _, match := range ignoreRegex.FindAllStringSubmatch(sql, -1) {
+		for _, id := range strings.Split(match[1], ",") {
+			id = strings.ToUpper(strings.TrimSpace(id))
+			if id != "" {
+				result[id] = true
+			}
+		}
+	}
+
+	return result
+}
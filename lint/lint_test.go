@@ -0,0 +1,74 @@
+package lint
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		name     string
+		sql      string
+		expected []string
+	}{
+		{
+			name:     "volatile default",
+			sql:      "set lock_timeout = '5s';\nalter table users add column token uuid default gen_random_uuid();",
+			expected: []string{"PGM001"},
+		},
+		{
+			name:     "constant default",
+			sql:      "set lock_timeout = '5s';\nalter table users add column active boolean default true;",
+			expected: []string{},
+		},
+		{
+			name:     "index without concurrently",
+			sql:      "create index users_email_idx on users(email);\ncreate unique index concurrently users_name_idx on users(name);",
+			expected: []string{"PGM002"},
+		},
+		{
+			name:     "set not null without check constraint",
+			sql:      "set lock_timeout = '5s';\nalter table users alter column email set not null;",
+			expected: []string{"PGM003"},
+		},
+		{
+			name: "set not null after validated check constraint",
+			sql: `set lock_timeout = '5s';
+alter table users add constraint email_not_null check (email is not null) not valid;
+alter table users validate constraint email_not_null;
+alter table users alter column email set not null;`,
+			expected: []string{},
+		},
+		{
+			name:     "column type change",
+			sql:      "set local lock_timeout = '5s';\nalter table users alter column age type bigint;\nalter table users alter age set data type int;",
+			expected: []string{"PGM004", "PGM004"},
+		},
+		{
+			name:     "missing lock timeout reported once",
+			sql:      "alter table users add column a int;\nalter table users add column b int;",
+			expected: []string{"PGM005"},
+		},
+		{
+			name:     "suppressed rules",
+			sql:      "-- pg-mig:lint-ignore PGM002, pgm005\ncreate index a_idx on a(id);\nalter table a add column b int;",
+			expected: []string{},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			findings := Lint("mig_1_up.sql", test.sql)
+
+			rules := make([]string, 0, len(findings))
+			for _, finding := range findings {
+				r.Equal("mig_1_up.sql", finding.File)
+				rules = append(rules, finding.Rule)
+			}
+
+			r.Equal(test.expected, rules)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Formats of log records
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configuration of logger created with New
+type Options struct {
+	Level  slog.Level
+	Format string
+}
+
+// New returns logger writing records in given format. Records with error level
+// are written to errOut and everything else to out.
+func New(out io.Writer, errOut io.Writer, options Options) (*slog.Logger, error) {
+	handlerOptions := &slog.HandlerOptions{Level: options.Level}
+
+	var handler splitHandler
+
+	switch options.Format {
+	case FormatText, "":
+		handler = splitHandler{out: slog.NewTextHandler(out, handlerOptions), errOut: slog.NewTextHandler(errOut, handlerOptions)}
+	case FormatJSON:
+		handler = splitHandler{out: slog.NewJSONHandler(out, handlerOptions), errOut: slog.NewJSONHandler(errOut, handlerOptions)}
+	default:
+		return nil, fmt.Errorf("logging error: unknown log format %s, expected %s or %s", options.Format, FormatText, FormatJSON)
+	}
+
+	return slog.New(&handler), nil
+}
+
+// Discard returns logger that drops all records
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+// Level returns log level selected with verbose and quiet flags
+func Level(verbose bool, quiet bool) (slog.Level, error) {
+	switch {
+	case verbose && quiet:
+		return slog.LevelInfo, fmt.Errorf("logging error: -v and -q can't be used together")
+	case verbose:
+		return slog.LevelDebug, nil
+	case quiet:
+		return slog.LevelError, nil
+	}
+
+	return slog.LevelInfo, nil
+}
+
+// splitHandler sends errors and other records to different handlers
+type splitHandler struct {
+	out    slog.Handler
+	errOut slog.Handler
+}
+
+func (h *splitHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.target(level).Enabled(ctx, level)
+}
+
+func (h *splitHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.target(record.Level).Handle(ctx, record)
+}
+
+func (h *splitHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &splitHandler{out: h.out.WithAttrs(attrs), errOut: h.errOut.WithAttrs(attrs)}
+}
+
+func (h *splitHandler) WithGroup(name string) slog.Handler {
+	return &splitHandler{out: h.out.WithGroup(name), errOut: h.errOut.WithGroup(name)}
+}
+
+func (h *splitHandler) target(level slog.Level) slog.Handler {
+	if level >= slog.LevelError {
+		return h.errOut
+	}
+
+	return h.out
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	r := require.New(t)
+
+	var out, errOut bytes.Buffer
+
+	logger, err := New(&out, &errOut, Options{Level: slog.LevelInfo, Format: FormatJSON})
+	r.NoError(err)
+
+	logger.Debug("query", "sql", "select 1")
+	logger.Info("up migration", "migration", "mig_1_up.sql")
+	logger.Error("failed")
+
+	var record map[string]interface{}
+	r.NoError(json.Unmarshal(out.Bytes(), &record), "only info record should be written to out")
+	r.Equal("up migration", record["msg"])
+	r.Equal("mig_1_up.sql", record["migration"])
+	r.Contains(record, "time")
+
+	r.NoError(json.Unmarshal(errOut.Bytes(), &record))
+	r.Equal("failed", record["msg"])
+	r.Equal("ERROR", record["level"])
+
+	out.Reset()
+	logger, err = New(&out, &errOut, Options{Level: slog.LevelDebug})
+	r.NoError(err)
+
+	logger.With("target", "eu").Debug("query", "sql", "select 1")
+	r.Contains(out.String(), `level=DEBUG msg=query target=eu sql="select 1"`)
+
+	_, err = New(&out, &errOut, Options{Format: "xml"})
+	r.Error(err)
+}
+
+func TestLevel(t *testing.T) {
+	r := require.New(t)
+
+	level, err := Level(false, false)
+	r.NoError(err)
+	r.Equal(slog.LevelInfo, level)
+
+	level, err = Level(true, false)
+	r.NoError(err)
+	r.Equal(slog.LevelDebug, level)
+
+	level, err = Level(false, true)
+	r.NoError(err)
+	r.Equal(slog.LevelError, level)
+
+	_, err = Level(true, true)
+	r.Error(err)
+}
+
+func TestDiscard(t *testing.T) {
+	r := require.New(t)
+
+	r.False(Discard().Enabled(context.Background(), slog.LevelError))
+}
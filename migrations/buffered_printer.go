@@ -3,26 +3,42 @@ package migrations
 import (
 	"fmt"
 	"strings"
+
+	"github.com/djordjev/pg-mig/subcommands"
 )
 
 type bufferedPrinter struct {
 	builder strings.Builder
+	// log receives everything that is printed when logger is set
+	log subcommands.Printer
 }
 
 func (b *bufferedPrinter) PrintUpMigration(text string) {
 	b.builder.WriteString("UP MIGRATION:" + text + "\n")
+	if b.log != nil {
+		b.log.PrintUpMigration(text)
+	}
 }
 
 func (b *bufferedPrinter) PrintDownMigration(text string) {
 	b.builder.WriteString("DOWN MIGRATION:" + text + "\n")
+	if b.log != nil {
+		b.log.PrintDownMigration(text)
+	}
 }
 
 func (b *bufferedPrinter) PrintError(text string) {
 	b.builder.WriteString("ERROR:" + text + "\n")
+	if b.log != nil {
+		b.log.PrintError(text)
+	}
 }
 
 func (b *bufferedPrinter) PrintSuccess(text string) {
 	b.builder.WriteString("SUCCESS:" + text + "\n")
+	if b.log != nil {
+		b.log.PrintSuccess(text)
+	}
 }
 
 func (b *bufferedPrinter) PrintMigrations(date string, onFS string, inDB string) {
@@ -38,6 +54,23 @@ func (b *bufferedPrinter) PrintMigrations(date string, onFS string, inDB string)
 
 	result := fmt.Sprintf("%s   |   %s / %s", date, fs, db)
 	b.builder.WriteString("ALL MIGRATIONS:" + result + "\n")
+	if b.log != nil {
+		b.log.PrintMigrations(date, onFS, inDB)
+	}
+}
+
+func (b *bufferedPrinter) PrintSQL(text string) {
+	b.builder.WriteString("SQL:" + text + "\n")
+	if b.log != nil {
+		b.log.PrintSQL(text)
+	}
+}
+
+func (b *bufferedPrinter) PrintRaw(text string) {
+	b.builder.WriteString(text + "\n")
+	if b.log != nil {
+		b.log.PrintRaw(text)
+	}
 }
 
 func (b *bufferedPrinter) SetNoColor(color bool) {}
@@ -2,6 +2,8 @@ package migrations
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/djordjev/pg-mig/filesystem"
@@ -13,10 +15,53 @@ import (
 
 var ConfigDirEnv = ""
 
+// MigrationFunc migration step implemented in Go that runs within migration transaction
+type MigrationFunc = models.MigrationFunc
+
+// HookFunc callback invoked around migrations
+type HookFunc = subcommands.HookFunc
+
+// HookContext metadata of the migration passed to hooks
+type HookContext = subcommands.HookContext
+
+// TxError failure of a migration transaction, matched with errors.As
+type TxError = models.TxError
+
+// Kinds of migration transaction failures, matched with errors.Is
+var (
+	ErrBeginFailed     = models.ErrBeginFailed
+	ErrMigrationFailed = models.ErrMigrationFailed
+	ErrMetaTableUpdate = models.ErrMetaTableUpdate
+	ErrCommitFailed    = models.ErrCommitFailed
+	ErrRollbackFailed  = models.ErrRollbackFailed
+)
+
+// RetryPolicy controls how connecting on database is retried
+type RetryPolicy = models.RetryPolicy
+
+// Tracer starts spans around the run and each executed migration
+type Tracer = subcommands.Tracer
+
+// Span traced unit of work started by Tracer
+type Span = subcommands.Span
+
+// Attribute key-value pair attached to span
+type Attribute = subcommands.Attribute
+
+// Metrics receives measurements of executed migrations
+type Metrics = subcommands.Metrics
+
 type migrations struct {
-	fs      filesystem.Filesystem
-	printer *bufferedPrinter
-	config  filesystem.Config
+	fs           filesystem.Filesystem
+	printer      *bufferedPrinter
+	config       filesystem.Config
+	goMigrations map[int64]models.GoMigration
+	hooks        subcommands.Hooks
+	logger       *slog.Logger
+	tracer       subcommands.Tracer
+	metrics      subcommands.Metrics
+	// guard refuses down migrations and destructive statements unless -yes is passed to Run
+	guard bool
 }
 
 // Creates new migration runner to control execution running
@@ -39,39 +84,157 @@ func NewRunner(host string, credentials string, dbName string, port int, workDir
 		ExternalConfig: &config,
 	}
 
-	return migrations{fs: fs, printer: newBufferedPrinter(), config: config}
+	return migrations{
+		fs:           fs,
+		printer:      newBufferedPrinter(),
+		config:       config,
+		goMigrations: make(map[int64]models.GoMigration),
+	}
+}
+
+// Register adds migration implemented in Go under given unix timestamp. It's executed
+// in the same ordered plan as SQL migration files and recorded in meta table the same way.
+func (m *migrations) Register(timestamp int64, name string, up MigrationFunc, down MigrationFunc) error {
+	if up == nil {
+		return fmt.Errorf("migrations error: go migration %s is missing up function", name)
+	}
+
+	if _, exists := m.goMigrations[timestamp]; exists {
+		return fmt.Errorf("migrations error: go migration with timestamp %d is already registered", timestamp)
+	}
+
+	m.goMigrations[timestamp] = models.GoMigration{Timestamp: timestamp, Name: name, Up: up, Down: down}
+
+	return nil
+}
+
+// SetMetaTable sets schema and name of the table storing applied migrations. Empty schema
+// means the first schema from search_path and empty name means the default __pg_mig_meta.
+func (m *migrations) SetMetaTable(schema string, name string) error {
+	_, err := models.NewMetaTable(schema, name)
+	if err != nil {
+		return err
+	}
+
+	m.config.MetaSchema = schema
+	m.config.MetaTable = name
+
+	return nil
+}
+
+// SetRetryPolicy sets how connecting on database is retried. Zero values keep defaults of
+// 3 attempts with backoff starting at 3s, unless only MaxWait is set which retries until it expires.
+func (m *migrations) SetRetryPolicy(policy RetryPolicy) {
+	m.config.ConnectAttempts = policy.Attempts
+	m.config.ConnectBackoff = ""
+	m.config.ConnectMaxWait = ""
+
+	if policy.Backoff > 0 {
+		m.config.ConnectBackoff = policy.Backoff.String()
+	}
+
+	if policy.MaxWait > 0 {
+		m.config.ConnectMaxWait = policy.MaxWait.String()
+	}
+}
+
+// SetDestructiveGuard enables refusing of down migrations and destructive statements (DROP,
+// TRUNCATE, DELETE without WHERE...) unless -yes is passed to Run. It's disabled by default
+// as there's nobody to confirm them when migrations run at service startup.
+func (m *migrations) SetDestructiveGuard(enabled bool) {
+	m.guard = enabled
+}
+
+// SetLogger sets logger receiving everything that is printed during the run together with
+// debug logs of connection attempts and queries. Prints are still available from GetPrints.
+func (m *migrations) SetLogger(logger *slog.Logger) {
+	m.logger = logger
+	m.printer.log = &subcommands.LogPrinter{Logger: logger}
+}
+
+// SetTracer sets tracer used to create a span for the run and a child span for each
+// executed migration, e.g. adapter for OpenTelemetry tracer
+func (m *migrations) SetTracer(tracer Tracer) {
+	m.tracer = tracer
+}
+
+// SetMetrics sets receiver of migration durations, failures and the current schema
+// version, e.g. adapter for Prometheus collectors
+func (m *migrations) SetMetrics(metrics Metrics) {
+	m.metrics = metrics
+}
+
+// BeforeAll adds callback invoked before any migration is executed
+func (m *migrations) BeforeAll(hook HookFunc) {
+	m.hooks.BeforeAll = append(m.hooks.BeforeAll, hook)
+}
+
+// AfterAll adds callback invoked after all migrations are executed
+func (m *migrations) AfterAll(hook HookFunc) {
+	m.hooks.AfterAll = append(m.hooks.AfterAll, hook)
+}
+
+// BeforeEach adds callback invoked before each up or down migration
+func (m *migrations) BeforeEach(hook HookFunc) {
+	m.hooks.BeforeEach = append(m.hooks.BeforeEach, hook)
+}
+
+// AfterEach adds callback invoked after each up or down migration
+func (m *migrations) AfterEach(hook HookFunc) {
+	m.hooks.AfterEach = append(m.hooks.AfterEach, hook)
 }
 
 func (m migrations) GetPrints() string {
 	return m.printer.GetAllPrints()
 }
 
-func (m migrations) Run(params []string) error {
+func (m migrations) Run(params []string) (err error) {
 
 	connectionString, err := m.config.GetConnectionString()
 	if err != nil {
 		return err
 	}
 
-	conn, err := models.BuildConnector(context.Background(), connectionString)
+	meta, err := models.NewMetaTable(m.config.MetaSchema, m.config.MetaTable)
+	if err != nil {
+		return err
+	}
+
+	connector := models.NewConnector(m.logger)
+
+	policy, err := subcommands.RetryPolicy(m.config)
+	if err != nil {
+		return err
+	}
+
+	conn, err := connector(context.Background(), connectionString, policy)
 	if err != nil {
 		return err
 	}
 
 	defer func() {
-		err := conn.Close(context.Background())
-		if err != nil {
-			panic("Unable to close connection to DB")
+		closeErr := conn.Close(context.Background())
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf("migrations error: unable to close connection to database %w", closeErr)
 		}
 	}()
 
 	base := subcommands.CommandBase{
 		Config:     m.config,
-		Models:     &models.ImplModels{Db: conn},
+		Models:     &models.ImplModels{Db: conn, Meta: meta},
 		Flags:      params,
 		Filesystem: m.fs,
 		Timer:      timer.Timer{Now: time.Now},
 		Printer:    m.printer,
+		Connector:  connector,
+		Meta:       meta,
+
+		GoMigrations: m.goMigrations,
+		Hooks:        m.hooks,
+		Tracer:       m.tracer,
+		Metrics:      m.metrics,
+
+		AllowDestructive: !m.guard,
 	}
 
 	init := subcommands.Initialize{CommandBase: base}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// Kinds of failures of migration transactions. Errors returned from Execute, SquashMigrations
+// and MarkMigrations can be matched against them with errors.Is.
+var (
+	ErrBeginFailed     = errors.New("unable to start transaction")
+	ErrMigrationFailed = errors.New("migration failed")
+	ErrMetaTableUpdate = errors.New("meta table update failed")
+	ErrCommitFailed    = errors.New("commit failed, state of the migration is unknown")
+	ErrRollbackFailed  = errors.New("rollback failed")
+)
+
+// TxError failure of a migration transaction
+type TxError struct {
+	// Kind one of ErrBeginFailed, ErrMigrationFailed, ErrMetaTableUpdate or ErrCommitFailed
+	Kind error
+	// Message describes what was executed when transaction failed
+	Message string
+	// Err error returned from database
+	Err error
+	// RollbackErr error of rolling back the transaction after failure, nil when rollback succeeded
+	RollbackErr error
+}
+
+func (e *TxError) Error() string {
+	message := fmt.Sprintf("db error: %s %v", e.Message, e.Err)
+	if e.RollbackErr != nil {
+		message = fmt.Sprintf("%s, %v %v", message, ErrRollbackFailed, e.RollbackErr)
+	}
+
+	return message
+}
+
+// Unwrap exposes kind of the failure, database error and rollback failure to errors.Is and errors.As
+func (e *TxError) Unwrap() []error {
+	errs := []error{e.Kind, e.Err}
+	if e.RollbackErr != nil {
+		errs = append(errs, ErrRollbackFailed, e.RollbackErr)
+	}
+
+	return errs
+}
+
+func txError(kind error, err error, format string, args ...interface{}) *TxError {
+	return &TxError{Kind: kind, Message: fmt.Sprintf(format, args...), Err: err}
+}
+
+// rollbackOnError rolls back transaction that hasn't been committed and reports failed
+// rollback within error returned by the function. It's meant to be deferred right after Begin.
+func rollbackOnError(tx pgx.Tx, err *error) {
+	rollbackErr := tx.Rollback(context.Background())
+	if *err == nil || rollbackErr == nil || errors.Is(rollbackErr, pgx.ErrTxClosed) {
+		return
+	}
+
+	var failure *TxError
+	if errors.As(*err, &failure) {
+		failure.RollbackErr = rollbackErr
+		return
+	}
+
+	*err = &TxError{Kind: ErrRollbackFailed, Message: "unable to roll back transaction", Err: *err, RollbackErr: rollbackErr}
+}
+
+// Postgres error codes of queries referring to objects that don't exist
+const (
+	undefinedTableCode = "42P01"
+	invalidSchemaCode  = "3F000"
+)
+
+// IsMissingTable returns whether error was caused by querying a table (or schema) that doesn't exist
+func IsMissingTable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == undefinedTableCode || pgErr.Code == invalidSchemaCode
+}
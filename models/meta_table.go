@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// DefaultMetaTable name of the table storing applied migrations when none is configured
+const DefaultMetaTable = "__pg_mig_meta"
+
+const seedsTableName = "__pg_mig_seeds"
+
+var identifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// MetaTable location of tables that pg-mig uses for storing state of the database.
+// Seeds table is kept in the same schema as meta table.
+type MetaTable struct {
+	Schema string
+	Name   string
+}
+
+// NewMetaTable validates given schema and table name and returns meta table location.
+// Empty schema means the first schema from search_path and empty name means DefaultMetaTable.
+func NewMetaTable(schema string, name string) (MetaTable, error) {
+	if name == "" {
+		name = DefaultMetaTable
+	}
+
+	if !identifierRegex.MatchString(name) {
+		return MetaTable{}, fmt.Errorf("db error: invalid meta table name %s, expected lowercase identifier", name)
+	}
+
+	if schema != "" && !identifierRegex.MatchString(schema) {
+		return MetaTable{}, fmt.Errorf("db error: invalid meta table schema %s, expected lowercase identifier", schema)
+	}
+
+	return MetaTable{Schema: schema, Name: name}, nil
+}
+
+// String returns name of meta table qualified with schema when schema is set
+func (meta MetaTable) String() string {
+	return meta.qualify(meta.tableName())
+}
+
+func (meta MetaTable) tableName() string {
+	if meta.Name == "" {
+		return DefaultMetaTable
+	}
+
+	return meta.Name
+}
+
+func (meta MetaTable) seedsTable() string {
+	return meta.qualify(seedsTableName)
+}
+
+func (meta MetaTable) qualify(name string) string {
+	if meta.Schema == "" {
+		return name
+	}
+
+	return meta.Schema + "." + name
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewMetaTable(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		name        string
+		schema      string
+		table       string
+		expected    string
+		returnError bool
+	}{
+		{name: "defaults", expected: DefaultMetaTable},
+		{name: "custom name", table: "billing_migrations", expected: "billing_migrations"},
+		{name: "custom schema", schema: "admin", expected: "admin.__pg_mig_meta"},
+		{name: "custom schema and name", schema: "admin", table: "billing", expected: "admin.billing"},
+		{name: "invalid name", table: "billing; drop table users", returnError: true},
+		{name: "invalid schema", schema: "Admin", returnError: true},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			meta, err := NewMetaTable(test.schema, test.table)
+			if test.returnError {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(test.expected, meta.String())
+		})
+	}
+}
+
+func TestMetaTableInSchema(t *testing.T) {
+	r := require.New(t)
+
+	meta := MetaTable{Schema: "admin", Name: "billing"}
+
+	mockConnection := &mockedDBConnection{}
+	mockConnection.On("Exec", mock.Anything, "create schema if not exists admin;", mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Twice()
+	mockConnection.On("Exec", mock.Anything, fmt.Sprintf(createMetaTableQuery, "admin.billing"), mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	mockConnection.On("Exec", mock.Anything, fmt.Sprintf(upgradeMetaTableQuery, "admin.billing"), mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	mockConnection.On("Exec", mock.Anything, fmt.Sprintf(createSeedsTableQuery, "admin.__pg_mig_seeds"), mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+
+	m := ImplModels{Db: mockConnection, Meta: meta}
+	r.NoError(m.CreateMetaTable())
+	r.NoError(m.CreateSeedsTable())
+
+	mockConnection.AssertExpectations(t)
+
+	script, err := Script(ExecutionContext{Timestamp: 1, Name: "mig_1_up.sql", IsUp: true}, meta)
+	r.NoError(err)
+	r.Contains(script, "insert into admin.billing (ts) values (to_timestamp(1));")
+	r.Contains(CreateMetaTableScript(meta), "create schema if not exists admin;\ncreate table if not exists admin.billing")
+}
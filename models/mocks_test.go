@@ -68,6 +68,15 @@ func (r *rowsImpl) Scan(dest ...interface{}) error {
 	cs := r.scans[r.cnt]
 	defer func() { r.cnt++ }()
 
+	// Multiple columns are provided as a slice of values
+	if values, ok := cs.([]interface{}); ok {
+		for i, v := range values {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(v))
+		}
+
+		return c.Error(0)
+	}
+
 	vres := reflect.ValueOf(cs)
 	reflect.ValueOf(dest[0]).Elem().Set(vres)
 
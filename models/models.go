@@ -3,34 +3,112 @@ package models
 import (
 	"context"
 	"fmt"
+	"github.com/djordjev/pg-mig/splitter"
 	"github.com/jackc/pgx/v4"
+	"strings"
 	"time"
 )
 
-const tableName = "__pg_mig_meta"
-
 // ImplModels implementation of models interface with underlying database
 type ImplModels struct {
-	Db DBConnection
+	Db   DBConnection
+	Meta MetaTable
 }
 
-// CreateMetaTable creates table named __pg_mig_meta
-// that will be used for storing migration info
+// CreateMetaTable creates meta table (__pg_mig_meta by default)
+// that will be used for storing migration info, or upgrades it when
+// it has been created by an older version
 func (models *ImplModels) CreateMetaTable() error {
 	db := models.Db
 
-	_, err := db.Exec(context.Background(), fmt.Sprintf(createMetaTableQuery, tableName))
+	err := models.createSchema()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(context.Background(), fmt.Sprintf(createMetaTableQuery, models.Meta))
 	if err != nil {
 		return fmt.Errorf("db error: unable to create meta table %w", err)
 	}
 
+	return models.UpgradeMetaTable()
+}
+
+// MetaTableOutdated returns whether meta table has been created by an older version
+// and needs to be upgraded. Meta table that doesn't exist is not outdated.
+func (models *ImplModels) MetaTableOutdated() (bool, error) {
+	rows, err := models.Db.Query(context.Background(), fmt.Sprintf(metaTableOutdatedQuery, models.Meta))
+	if err != nil {
+		return false, fmt.Errorf("db error: unable to check meta table %w", err)
+	}
+	defer rows.Close()
+
+	outdated := false
+	if rows.Next() {
+		err = rows.Scan(&outdated)
+		if err != nil {
+			return false, fmt.Errorf("db error: unable to check meta table %w", err)
+		}
+	}
+
+	return outdated, nil
+}
+
+// UpgradeMetaTable adds columns introduced in newer versions to existing meta table.
+// It does nothing when meta table doesn't exist yet.
+func (models *ImplModels) UpgradeMetaTable() error {
+	_, err := models.Db.Exec(context.Background(), fmt.Sprintf(upgradeMetaTableQuery, models.Meta))
+	if err != nil {
+		return fmt.Errorf("db error: unable to upgrade meta table %w", err)
+	}
+
 	return nil
 }
 
+// CreateSeedsTable creates table named __pg_mig_seeds
+// that will be used for tracking applied seeds
+func (models *ImplModels) CreateSeedsTable() error {
+	err := models.createSchema()
+	if err != nil {
+		return err
+	}
+
+	_, err = models.Db.Exec(context.Background(), fmt.Sprintf(createSeedsTableQuery, models.Meta.seedsTable()))
+	if err != nil {
+		return fmt.Errorf("db error: unable to create seeds table %w", err)
+	}
+
+	return nil
+}
+
+// createSchema creates configured schema of meta table if it doesn't exist
+func (models *ImplModels) createSchema() error {
+	if models.Meta.Schema == "" {
+		return nil
+	}
+
+	_, err := models.Db.Exec(context.Background(), fmt.Sprintf("create schema if not exists %s;", models.Meta.Schema))
+	if err != nil {
+		return fmt.Errorf("db error: unable to create schema %s for meta table %w", models.Meta.Schema, err)
+	}
+
+	return nil
+}
+
+// GetSeedsList - fetches timestamps of seeds that has
+// been applied in current DB
+func (models *ImplModels) GetSeedsList() ([]int64, error) {
+	return models.getTimestamps(fmt.Sprintf(getSeedsListQuery, models.Meta.seedsTable()))
+}
+
 // GetMigrationsList - fetches timestamps of migrations that has
 // been executed in current DB
 func (models *ImplModels) GetMigrationsList() ([]int64, error) {
-	rows, err := models.Db.Query(context.Background(), fmt.Sprintf(getMigrationsListQuery, tableName))
+	return models.getTimestamps(fmt.Sprintf(getMigrationsListQuery, models.Meta))
+}
+
+func (models *ImplModels) getTimestamps(query string) ([]int64, error) {
+	rows, err := models.Db.Query(context.Background(), query)
 	if err != nil {
 		return nil, fmt.Errorf("db error: unable to query for migrations list %w", err)
 	}
@@ -52,47 +130,178 @@ func (models *ImplModels) GetMigrationsList() ([]int64, error) {
 	return result, nil
 }
 
+// CreateDatabase creates a new empty database with given name
+func (models *ImplModels) CreateDatabase(name string) error {
+	query := fmt.Sprintf("create database %s", pgx.Identifier{name}.Sanitize())
+
+	_, err := models.Db.Exec(context.Background(), query)
+	if err != nil {
+		return fmt.Errorf("db error: unable to create database %s %w", name, err)
+	}
+
+	return nil
+}
+
+// DropDatabase drops database with given name if it exists
+func (models *ImplModels) DropDatabase(name string) error {
+	query := fmt.Sprintf("drop database if exists %s", pgx.Identifier{name}.Sanitize())
+
+	_, err := models.Db.Exec(context.Background(), query)
+	if err != nil {
+		return fmt.Errorf("db error: unable to drop database %s %w", name, err)
+	}
+
+	return nil
+}
+
+// GetSchemaSnapshot - returns sorted textual description of catalog objects
+// (tables, columns, indexes, constraints, sequences, views, functions and triggers)
+// in current DB. Meta table is not included.
+func (models *ImplModels) GetSchemaSnapshot() ([]string, error) {
+	rows, err := models.Db.Query(context.Background(), fmt.Sprintf(getSchemaSnapshotQuery, models.Meta.tableName(), seedsTableName))
+	if err != nil {
+		return nil, fmt.Errorf("db error: unable to query database catalog %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]string, 0, 100)
+
+	for rows.Next() {
+		var line string
+
+		err = rows.Scan(&line)
+		if err != nil {
+			return result, fmt.Errorf("db error: unable to scan database catalog %w", err)
+		}
+
+		result = append(result, line)
+	}
+
+	return result, nil
+}
+
+// GetMigrationDurations returns execution times of applied migrations keyed by their timestamp.
+// Migrations applied before durations were recorded are missing from the result.
+func (models *ImplModels) GetMigrationDurations() (map[int64]time.Duration, error) {
+	rows, err := models.Db.Query(context.Background(), fmt.Sprintf(getMigrationDurationsQuery, models.Meta))
+	if err != nil {
+		return nil, fmt.Errorf("db error: unable to get migration durations %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]time.Duration)
+
+	for rows.Next() {
+		var ts time.Time
+		var durationMs int64
+
+		err = rows.Scan(&ts, &durationMs)
+		if err != nil {
+			return nil, fmt.Errorf("db error: unable to scan migration duration %w", err)
+		}
+
+		result[ts.Unix()] = time.Duration(durationMs) * time.Millisecond
+	}
+
+	return result, nil
+}
+
+// GetRepeatableChecksums - fetches checksums of last applied version
+// of each repeatable migration keyed by its name
+func (models *ImplModels) GetRepeatableChecksums() (map[string]string, error) {
+	rows, err := models.Db.Query(context.Background(), fmt.Sprintf(getRepeatableChecksumsQuery, models.Meta))
+	if err != nil {
+		return nil, fmt.Errorf("db error: unable to query for repeatable migrations %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+
+	for rows.Next() {
+		var name, checksum string
+
+		err = rows.Scan(&name, &checksum)
+		if err != nil {
+			return result, fmt.Errorf("db error: unable to scan returned rows from meta table %w", err)
+		}
+
+		result[name] = checksum
+	}
+
+	return result, nil
+}
+
 // SquashMigrations deletes all migration instances in meta table between given timestamps (both inclusive).
 // and writes a new squash migration with timestamp set to `to` variable value
-func (models *ImplModels) SquashMigrations(from time.Time, to time.Time, name int64) error {
+func (models *ImplModels) SquashMigrations(from time.Time, to time.Time, name int64) (err error) {
 	tx, err := models.Db.Begin(context.Background())
 	if err != nil {
-		return fmt.Errorf("unable to start transaction %w", err)
+		return txError(ErrBeginFailed, err, "unable to start transaction")
 	}
 
-	defer func() {
-		err := tx.Rollback(context.Background())
-		if err != nil && err != pgx.ErrTxClosed {
-			panic(err)
-		}
-	}()
+	defer rollbackOnError(tx, &err)
 
-	delQuery := fmt.Sprintf("delete from %s where ts >= $1 and ts <= $2;", tableName)
+	delQuery := fmt.Sprintf("delete from %s where ts >= $1 and ts <= $2 and repeatable is null;", models.Meta)
 
 	_, err = tx.Exec(context.Background(), delQuery, from, to)
 	if err != nil {
-		return fmt.Errorf("db error: unable to squash migrations %w", err)
+		return txError(ErrMetaTableUpdate, err, "unable to squash migrations")
 	}
 
-	addQuery := fmt.Sprintf("insert into %s (ts) values ($1);", tableName)
+	addQuery := fmt.Sprintf("insert into %s (ts) values ($1);", models.Meta)
 	_, err = tx.Exec(context.Background(), addQuery, time.Unix(name, 0))
 	if err != nil {
-		return fmt.Errorf("db error: unable to write squash migration %w", err)
+		return txError(ErrMetaTableUpdate, err, "unable to write squash migration")
 	}
 
 	err = tx.Commit(context.Background())
 	if err != nil {
-		panic(err)
+		return txError(ErrCommitFailed, err, "unable to commit squash migration %d", name)
 	}
 
 	return nil
 }
 
-func updateMetaTable(executionContext *ExecutionContext, tx pgx.Tx) error {
+// MarkMigrations records given migrations in meta table as applied
+// without executing them
+func (models *ImplModels) MarkMigrations(timestamps []int64) (err error) {
+	tx, err := models.Db.Begin(context.Background())
+	if err != nil {
+		return txError(ErrBeginFailed, err, "unable to start transaction")
+	}
+
+	defer rollbackOnError(tx, &err)
+
+	for _, ts := range timestamps {
+		err = models.updateMetaTable(&ExecutionContext{Timestamp: ts, IsUp: true}, tx)
+		if err != nil {
+			return txError(ErrMetaTableUpdate, err, "unable to mark migration %d as applied", ts)
+		}
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return txError(ErrCommitFailed, err, "unable to commit marked migrations")
+	}
+
+	return nil
+}
+
+func (models *ImplModels) updateMetaTable(executionContext *ExecutionContext, tx pgx.Tx) error {
+	if executionContext.Repeatable {
+		return models.updateRepeatable(executionContext, tx)
+	}
+
+	if executionContext.Seed {
+		seedQuery := fmt.Sprintf("insert into %s (ts, name) values ($1, $2);", models.Meta.seedsTable())
+		_, err := tx.Exec(context.Background(), seedQuery, time.Unix(executionContext.Timestamp, 0), executionContext.Name)
+		return err
+	}
+
 	unixTs := time.Unix(executionContext.Timestamp, 0)
 
-	upQuery := fmt.Sprintf("insert into %s (ts) values ($1);", tableName)
-	downQuery := fmt.Sprintf("delete from %s where ts = $1", tableName)
+	upQuery := fmt.Sprintf("insert into %s (ts) values ($1);", models.Meta)
+	downQuery := fmt.Sprintf("delete from %s where ts = $1 and repeatable is null", models.Meta)
 
 	var err error
 	if executionContext.IsUp {
@@ -104,33 +313,145 @@ func updateMetaTable(executionContext *ExecutionContext, tx pgx.Tx) error {
 	return err
 }
 
+// storesDuration returns whether execution time of migration is saved into meta table
+func storesDuration(executionContext *ExecutionContext) bool {
+	return executionContext.IsUp && !executionContext.Repeatable && !executionContext.Seed
+}
+
+// qualifiedMeta returns meta table qualified with schema it resolves to. Migrations can change
+// search_path so meta table has to be resolved before they are executed.
+func (models *ImplModels) qualifiedMeta(tx pgx.Tx) (MetaTable, error) {
+	if models.Meta.Schema != "" {
+		return models.Meta, nil
+	}
+
+	meta := models.Meta
+	err := tx.QueryRow(context.Background(), metaTableSchemaQuery, models.Meta.String()).Scan(&meta.Schema)
+
+	return meta, err
+}
+
+// storeDuration saves execution time of applied up migration into meta table
+func storeDuration(executionContext *ExecutionContext, meta MetaTable, tx pgx.Tx, duration time.Duration) error {
+	if !storesDuration(executionContext) {
+		return nil
+	}
+
+	query := fmt.Sprintf("update %s set duration_ms = $1 where ts = $2 and repeatable is null;", meta)
+	_, err := tx.Exec(context.Background(), query, duration.Milliseconds(), time.Unix(executionContext.Timestamp, 0))
+
+	return err
+}
+
+// updateRepeatable replaces previously stored checksum of repeatable migration
+func (models *ImplModels) updateRepeatable(executionContext *ExecutionContext, tx pgx.Tx) error {
+	delQuery := fmt.Sprintf("delete from %s where repeatable = $1;", models.Meta)
+	addQuery := fmt.Sprintf("insert into %s (ts, repeatable, checksum) values (now(), $1, $2);", models.Meta)
+
+	_, err := tx.Exec(context.Background(), delQuery, executionContext.Name)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(context.Background(), addQuery, executionContext.Name, executionContext.Checksum)
+
+	return err
+}
+
+// copyFromStdin streams inline data rows of COPY ... FROM stdin statement
+// through the connection of migration transaction
+var copyFromStdin = func(ctx context.Context, tx pgx.Tx, sql string, data string) error {
+	_, err := tx.Conn().PgConn().CopyFrom(ctx, strings.NewReader(data), sql)
+	return err
+}
+
+// executeStatements executes statements of migration one by one reporting progress after each of them
+func executeStatements(executionContext *ExecutionContext, tx pgx.Tx) error {
+	statements := splitter.Split(executionContext.Sql)
+
+	for i, statement := range statements {
+		start := time.Now()
+
+		var err error
+		if statement.IsCopyFromStdin() {
+			err = copyFromStdin(context.Background(), tx, statement.Text, statement.CopyData)
+		} else {
+			_, err = tx.Exec(context.Background(), statement.Text)
+		}
+		if err != nil {
+			return fmt.Errorf("statement %d at line %d failed %w", i+1, statement.Line, err)
+		}
+
+		if executionContext.Progress != nil {
+			executionContext.Progress(StatementProgress{
+				Index:     i + 1,
+				Total:     len(statements),
+				Line:      statement.Line,
+				Statement: statement.Text,
+				Duration:  time.Since(start),
+			})
+		}
+	}
+
+	return nil
+}
+
+// ExecuteHook runs SQL of a hook outside of migration transactions
+func (models *ImplModels) ExecuteHook(sql string) error {
+	_, err := models.Db.Exec(context.Background(), sql)
+	if err != nil {
+		return fmt.Errorf("db error: unable to execute hook %w", err)
+	}
+
+	return nil
+}
+
 // Execute runs a migration within a transaction and updates meta table
-func (models *ImplModels) Execute(executionContext ExecutionContext) error {
+func (models *ImplModels) Execute(executionContext ExecutionContext) (err error) {
 	tx, err := models.Db.Begin(context.Background())
 	if err != nil {
-		return err
+		return txError(ErrBeginFailed, err, "unable to start transaction for migration %s", executionContext.Name)
 	}
 
-	err = updateMetaTable(&executionContext, tx)
+	defer rollbackOnError(tx, &err)
+
+	err = models.updateMetaTable(&executionContext, tx)
 	if err != nil {
-		return fmt.Errorf("db error: unable to update meta table %w", err)
+		return txError(ErrMetaTableUpdate, err, "unable to update meta table")
+	}
+
+	meta := models.Meta
+	if storesDuration(&executionContext) {
+		meta, err = models.qualifiedMeta(tx)
+		if err != nil {
+			return txError(ErrMetaTableUpdate, err, "unable to resolve schema of meta table")
+		}
 	}
 
-	defer func() {
-		err := tx.Rollback(context.Background())
-		if err != nil && err != pgx.ErrTxClosed {
-			panic(err)
+	start := time.Now()
+
+	if executionContext.Func != nil {
+		ctx := executionContext.Context
+		if ctx == nil {
+			ctx = context.Background()
 		}
-	}()
 
-	_, err = tx.Exec(context.Background(), executionContext.Sql)
+		err = executionContext.Func(ctx, tx)
+	} else {
+		err = executeStatements(&executionContext, tx)
+	}
+	if err != nil {
+		return txError(ErrMigrationFailed, err, "unable to execute migration file %s. Error returned", executionContext.Name)
+	}
+
+	err = storeDuration(&executionContext, meta, tx, time.Since(start))
 	if err != nil {
-		return fmt.Errorf("db error: unable to execute migration file %s. Error returned %w", executionContext.Name, err)
+		return txError(ErrMetaTableUpdate, err, "unable to store duration of migration %s", executionContext.Name)
 	}
 
 	err = tx.Commit(context.Background())
 	if err != nil {
-		panic(err)
+		return txError(ErrCommitFailed, err, "unable to commit migration %s", executionContext.Name)
 	}
 
 	return nil
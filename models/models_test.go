@@ -1,9 +1,11 @@
 package models
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -21,21 +23,28 @@ func TestCreateMetaTable(t *testing.T) {
 	}{
 		{
 			name:  "successfully executes",
-			query: fmt.Sprintf(createMetaTableQuery, tableName),
+			query: fmt.Sprintf(createMetaTableQuery, DefaultMetaTable),
 			err:   nil,
 		},
 		{
 			name:  "returns error",
-			query: fmt.Sprintf(createMetaTableQuery, tableName),
+			query: fmt.Sprintf(createMetaTableQuery, DefaultMetaTable),
 			err:   errors.New("some error"),
 		},
 	}
 
 	for _, val := range table {
 		t.Run(val.name, func(t *testing.T) {
+			r.NotContains(val.query, "alter table", "existing meta table must not be locked")
+			r.Contains(val.query, "duration_ms bigint")
+
 			mockConnection := &mockedDBConnection{}
 			mockConnection.On("Exec", mock.Anything, val.query, mock.Anything).
 				Return(pgconn.CommandTag{}, val.err)
+			if val.err == nil {
+				mockConnection.On("Exec", mock.Anything, fmt.Sprintf(upgradeMetaTableQuery, DefaultMetaTable), mock.Anything).
+					Return(pgconn.CommandTag{}, nil).Once()
+			}
 
 			m := ImplModels{Db: mockConnection}
 			err := m.CreateMetaTable()
@@ -52,6 +61,62 @@ func TestCreateMetaTable(t *testing.T) {
 
 }
 
+func TestUpgradeMetaTable(t *testing.T) {
+	r := require.New(t)
+
+	meta := MetaTable{Schema: "admin", Name: "migrations"}
+	query := fmt.Sprintf(upgradeMetaTableQuery, meta)
+
+	r.NotContains(query, "%!", "query is not formatted properly")
+	r.Contains(query, "to_regclass('admin.migrations') is not null")
+	r.Contains(query, "alter table admin.migrations add column if not exists checksum text;")
+	r.Contains(query, "alter table admin.migrations add column if not exists duration_ms bigint;")
+
+	mockConnection := &mockedDBConnection{}
+	mockConnection.On("Exec", mock.Anything, query, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	mockConnection.On("Exec", mock.Anything, query, mock.Anything).Return(pgconn.CommandTag{}, demoError).Once()
+
+	m := ImplModels{Db: mockConnection, Meta: meta}
+
+	r.NoError(m.UpgradeMetaTable())
+	r.Error(m.UpgradeMetaTable())
+
+	mockConnection.AssertExpectations(t)
+}
+
+func TestMetaTableOutdated(t *testing.T) {
+	r := require.New(t)
+
+	meta := MetaTable{Schema: "admin", Name: "migrations"}
+	query := fmt.Sprintf(metaTableOutdatedQuery, meta)
+
+	r.NotContains(query, "%!", "query is not formatted properly")
+	r.Contains(query, "to_regclass('admin.migrations') is not null")
+
+	for _, outdated := range []bool{true, false} {
+		db := &mockedDBConnection{}
+		rows := &rowsImpl{scans: []interface{}{outdated}}
+
+		db.On("Query", mock.Anything, query, mock.Anything).Return(rows, nil).Once()
+		rows.On("Next").Return(true).Once()
+		rows.On("Scan", mock.Anything).Return(nil)
+		rows.On("Close")
+
+		m := ImplModels{Db: db, Meta: meta}
+
+		result, err := m.MetaTableOutdated()
+		r.NoError(err)
+		r.Equal(outdated, result)
+	}
+
+	db := &mockedDBConnection{}
+	db.On("Query", mock.Anything, query, mock.Anything).Return(&rowsImpl{}, demoError)
+
+	m := ImplModels{Db: db, Meta: meta}
+	_, err := m.MetaTableOutdated()
+	r.True(errors.Is(err, demoError))
+}
+
 func TestGetMigrationsList(t *testing.T) {
 	r := require.New(t)
 	t1, _ := time.Parse(time.RFC3339, "2020-09-20T15:04:05Z")
@@ -103,7 +168,7 @@ func TestGetMigrationsList(t *testing.T) {
 			db := &mockedDBConnection{}
 			rows := &rowsImpl{}
 
-			db.On("Query", mock.Anything, fmt.Sprintf(getMigrationsListQuery, tableName), mock.Anything).
+			db.On("Query", mock.Anything, fmt.Sprintf(getMigrationsListQuery, DefaultMetaTable), mock.Anything).
 				Return(rows, val.queryError)
 
 			rows.On("Close")
@@ -137,6 +202,15 @@ func TestGetMigrationsList(t *testing.T) {
 
 }
 
+var durationQuery = fmt.Sprintf("update public.%s set duration_ms = $1 where ts = $2 and repeatable is null;", DefaultMetaTable)
+
+// expectMetaSchema resolves unqualified meta table to public schema for up to two executed migrations
+func expectMetaSchema(tx *txImpl) {
+	rows := &rowsImpl{scans: []interface{}{"public", "public"}}
+	rows.On("Scan", mock.Anything).Return(nil)
+	tx.On("QueryRow", mock.Anything, metaTableSchemaQuery, []interface{}{DefaultMetaTable}).Return(rows)
+}
+
 func TestExecute(t *testing.T) {
 	r := require.New(t)
 
@@ -146,13 +220,14 @@ func TestExecute(t *testing.T) {
 		expectedMeta     string
 		metaErr          error
 		sqlErr           error
+		durationErr      error
 		commitErr        error
 		returnError      error
 	}{
 		{
 			name:             "executes up migration",
 			executionContext: ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true},
-			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", tableName),
+			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable),
 			metaErr:          nil,
 			sqlErr:           nil,
 			commitErr:        nil,
@@ -161,34 +236,41 @@ func TestExecute(t *testing.T) {
 		{
 			name:             "executes up migration meta table error",
 			executionContext: ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true},
-			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", tableName),
+			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable),
 			metaErr:          errors.New("meta error"),
 			sqlErr:           nil,
 			commitErr:        nil,
-			returnError:      errors.New("meta error"),
+			returnError:      ErrMetaTableUpdate,
 		},
 		{
 			name:             "executes up migration execution error",
 			executionContext: ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true},
-			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", tableName),
+			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable),
 			metaErr:          nil,
 			sqlErr:           errors.New("exec error"),
 			commitErr:        nil,
-			returnError:      errors.New("exec error"),
+			returnError:      ErrMigrationFailed,
+		},
+		{
+			name:             "executes up migration duration error",
+			executionContext: ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true},
+			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable),
+			durationErr:      errors.New("duration error"),
+			returnError:      ErrMetaTableUpdate,
 		},
 		{
 			name:             "executes up migration commit error",
 			executionContext: ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true},
-			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", tableName),
+			expectedMeta:     fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable),
 			metaErr:          nil,
 			sqlErr:           nil,
 			commitErr:        errors.New("commit error"),
-			returnError:      nil,
+			returnError:      ErrCommitFailed,
 		},
 		{
 			name:             "executes down migration",
 			executionContext: ExecutionContext{Timestamp: 444, Name: "demo_name", Sql: "sql dn", IsUp: false},
-			expectedMeta:     fmt.Sprintf("delete from %s where ts = $1", tableName),
+			expectedMeta:     fmt.Sprintf("delete from %s where ts = $1 and repeatable is null", DefaultMetaTable),
 			metaErr:          nil,
 			sqlErr:           nil,
 			commitErr:        nil,
@@ -205,6 +287,9 @@ func TestExecute(t *testing.T) {
 
 			m := ImplModels{Db: &mockConn}
 
+			// Resolves schema of meta table before running migration
+			expectMetaSchema(&tx)
+
 			// Calls exec on tx to update meta table
 			ts := time.Unix(test.executionContext.Timestamp, 0)
 			tx.On("Exec", mock.Anything, test.expectedMeta, []interface{}{ts}).
@@ -214,26 +299,92 @@ func TestExecute(t *testing.T) {
 			tx.On("Exec", mock.Anything, test.executionContext.Sql, mock.Anything).
 				Return(pgconn.CommandTag{}, test.sqlErr).Once()
 
+			// Stores duration of up migrations
+			durationArgs := mock.MatchedBy(func(args []interface{}) bool {
+				return len(args) == 2 && args[1] == ts
+			})
+			tx.On("Exec", mock.Anything, durationQuery, durationArgs).
+				Return(pgconn.CommandTag{}, test.durationErr).Once()
+
 			// Calls commit
 			tx.On("Commit", mock.Anything).Return(test.commitErr).Once()
 
 			// Calls rollback
 			tx.On("Rollback", mock.Anything).Return(nil)
 
-			var err error
-			if test.commitErr == nil {
-				err = m.Execute(test.executionContext)
-			} else {
-				r.PanicsWithError(test.commitErr.Error(), func() {
-					err = m.Execute(test.executionContext)
-				})
-			}
+			err := m.Execute(test.executionContext)
 
 			if test.returnError != nil {
-				r.Error(test.returnError)
+				r.Error(err)
+				r.True(errors.Is(err, test.returnError), "unexpected kind of error %v", err)
+
+				var txErr *TxError
+				r.True(errors.As(err, &txErr))
+				r.Nil(txErr.RollbackErr)
+				tx.AssertCalled(t, "Rollback", mock.Anything)
 			} else {
 				r.NoError(err)
 			}
+
+			if test.executionContext.IsUp && test.metaErr == nil && test.sqlErr == nil {
+				tx.AssertCalled(t, "Exec", mock.Anything, durationQuery, durationArgs)
+			}
+		})
+	}
+}
+
+func TestExecuteResetsSearchPath(t *testing.T) {
+	table := []struct {
+		name          string
+		meta          MetaTable
+		resolves      bool
+		durationQuery string
+	}{
+		{
+			name:          "meta table without schema is resolved before migration",
+			resolves:      true,
+			durationQuery: durationQuery,
+		},
+		{
+			name:          "meta table with schema is used as is",
+			meta:          MetaTable{Schema: "pgmig", Name: "history"},
+			durationQuery: "update pgmig.history set duration_ms = $1 where ts = $2 and repeatable is null;",
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			sql := "SELECT pg_catalog.set_config('search_path', '', true)"
+			calls := make([]string, 0)
+			record := func(args mock.Arguments) { calls = append(calls, args.String(1)) }
+
+			mockConn := mockedDBConnection{}
+			tx := txImpl{}
+
+			mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+
+			rows := &rowsImpl{scans: []interface{}{"public"}}
+			rows.On("Scan", mock.Anything).Return(nil)
+			tx.On("QueryRow", mock.Anything, metaTableSchemaQuery, []interface{}{DefaultMetaTable}).Return(rows).Run(record)
+
+			tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts) values ($1);", test.meta), mock.Anything).
+				Return(pgconn.CommandTag{}, nil).Once()
+			tx.On("Exec", mock.Anything, sql, mock.Anything).Return(pgconn.CommandTag{}, nil).Once().Run(record)
+			tx.On("Exec", mock.Anything, test.durationQuery, mock.Anything).Return(pgconn.CommandTag{}, nil).Once().Run(record)
+			tx.On("Commit", mock.Anything).Return(nil).Once()
+			tx.On("Rollback", mock.Anything).Return(nil)
+
+			m := ImplModels{Db: &mockConn, Meta: test.meta}
+
+			r.NoError(m.Execute(ExecutionContext{Timestamp: 123, Name: "mig_123_baseline_up.sql", Sql: sql + ";", IsUp: true}))
+
+			expected := []string{sql, test.durationQuery}
+			if test.resolves {
+				expected = append([]string{metaTableSchemaQuery}, expected...)
+			}
+			r.Equal(expected, calls)
 		})
 	}
 }
@@ -286,13 +437,13 @@ func TestSquashMigrations(t *testing.T) {
 			to := int64(10000)
 			name := int64(900)
 
-			expectedDelQuery := fmt.Sprintf("delete from %s where ts >= $1 and ts <= $2;", tableName)
+			expectedDelQuery := fmt.Sprintf("delete from %s where ts >= $1 and ts <= $2 and repeatable is null;", DefaultMetaTable)
 
 			expectedTimes := []interface{}{time.Unix(from, 0), time.Unix(to, 0)}
 			tx.On("Exec", mock.Anything, expectedDelQuery, expectedTimes).
 				Return(pgconn.CommandTag{}, test.delError).Once()
 
-			expectedAddQuery := fmt.Sprintf("insert into %s (ts) values ($1);", tableName)
+			expectedAddQuery := fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable)
 
 			tx.On("Exec", mock.Anything, expectedAddQuery, []interface{}{time.Unix(name, 0)}).
 				Return(pgconn.CommandTag{}, test.addError).Once()
@@ -301,21 +452,414 @@ func TestSquashMigrations(t *testing.T) {
 
 			tx.On("Rollback", mock.Anything).Return(nil)
 
-			var err error
-			if test.commitError == nil {
-				err = m.SquashMigrations(time.Unix(from, 0), time.Unix(to, 0), name)
+			err := m.SquashMigrations(time.Unix(from, 0), time.Unix(to, 0), name)
+
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if test.commitError != nil {
+				r.True(errors.Is(err, ErrCommitFailed))
+				r.True(errors.Is(err, test.commitError))
+			}
+
+		})
+	}
+}
+
+func TestMarkMigrations(t *testing.T) {
+	r := require.New(t)
+	table := []struct {
+		name        string
+		timestamps  []int64
+		txError     error
+		addError    error
+		returnError bool
+	}{
+		{
+			name:       "marks migrations",
+			timestamps: []int64{100, 200},
+		},
+		{
+			name:        "tx error",
+			timestamps:  []int64{100},
+			txError:     errors.New("tx error"),
+			returnError: true,
+		},
+		{
+			name:        "add error",
+			timestamps:  []int64{100, 200},
+			addError:    errors.New("add error"),
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			mockConn := mockedDBConnection{}
+			tx := txImpl{}
+
+			mockConn.On("Begin", mock.Anything).Return(&tx, test.txError)
+
+			m := ImplModels{Db: &mockConn}
+
+			expectedAddQuery := fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable)
+			for _, ts := range test.timestamps {
+				tx.On("Exec", mock.Anything, expectedAddQuery, []interface{}{time.Unix(ts, 0)}).
+					Return(pgconn.CommandTag{}, test.addError).Once()
+			}
+
+			tx.On("Commit", mock.Anything).Return(nil).Once()
+			tx.On("Rollback", mock.Anything).Return(nil)
+
+			err := m.MarkMigrations(test.timestamps)
 
-				if test.returnError {
-					r.Error(err)
-				} else {
-					r.NoError(err)
-				}
+			if test.returnError {
+				r.Error(err)
 			} else {
-				r.PanicsWithError(test.commitError.Error(), func() {
-					err = m.SquashMigrations(time.Unix(from, 0), time.Unix(to, 0), name)
-				})
+				r.NoError(err)
+				tx.AssertNumberOfCalls(t, "Exec", len(test.timestamps))
 			}
+		})
+	}
+}
+
+func TestExecuteRollbackError(t *testing.T) {
+	r := require.New(t)
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
+
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+
+	sqlErr := errors.New("exec error")
+	rollbackErr := errors.New("connection lost")
+
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable), mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, "sql", mock.Anything).Return(pgconn.CommandTag{}, sqlErr).Once()
+	tx.On("Rollback", mock.Anything).Return(rollbackErr).Once()
+	expectMetaSchema(&tx)
+
+	m := ImplModels{Db: &mockConn}
+	err := m.Execute(ExecutionContext{Timestamp: 123, Name: "demo_name", Sql: "sql", IsUp: true})
+
+	r.Error(err)
+	r.True(errors.Is(err, ErrMigrationFailed))
+	r.True(errors.Is(err, sqlErr))
+	r.True(errors.Is(err, ErrRollbackFailed))
+	r.True(errors.Is(err, rollbackErr))
+	r.Equal("db error: unable to execute migration file demo_name. Error returned statement 1 at line 1 failed exec error, rollback failed connection lost", err.Error())
+}
+
+func TestCreateDropDatabase(t *testing.T) {
+	r := require.New(t)
+
+	mockConnection := &mockedDBConnection{}
+	mockConnection.On("Exec", mock.Anything, `create database "scratch_db"`, mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	mockConnection.On("Exec", mock.Anything, `drop database if exists "scratch_db"`, mock.Anything).
+		Return(pgconn.CommandTag{}, demoError).Once()
+
+	m := ImplModels{Db: mockConnection}
+
+	r.NoError(m.CreateDatabase("scratch_db"))
+	r.Error(m.DropDatabase("scratch_db"))
+
+	mockConnection.AssertExpectations(t)
+}
+
+func TestGetSchemaSnapshot(t *testing.T) {
+	r := require.New(t)
+
+	db := &mockedDBConnection{}
+	rows := &rowsImpl{}
+
+	query := fmt.Sprintf(getSchemaSnapshotQuery, DefaultMetaTable, seedsTableName)
+	r.NotContains(query, "%!", "query is not formatted properly")
+	r.NotContains(query, "like", "underscores in meta table name must not be used as wildcards")
+	r.NotContains(query, "left(", "tables whose names start with meta table name must stay in snapshot")
+	r.Contains(query, "table_name not in ('__pg_mig_meta', '__pg_mig_seeds')")
+	r.Contains(query, "sequence_name not in ('__pg_mig_meta_id_seq', '__pg_mig_seeds_id_seq')")
+
+	db.On("Query", mock.Anything, query, mock.Anything).Return(rows, nil)
+	rows.On("Close")
+	rows.On("Scan", mock.Anything).Return(nil)
+	rows.On("Next").Return(true).Twice()
+	rows.On("Next").Return(false).Once()
+	rows.scans = []interface{}{"table public.a BASE TABLE", "column public.a.id integer nullable=NO default="}
+
+	m := ImplModels{Db: db}
+	res, err := m.GetSchemaSnapshot()
+
+	r.NoError(err)
+	r.Equal([]string{"table public.a BASE TABLE", "column public.a.id integer nullable=NO default="}, res)
+}
+
+func TestGetRepeatableChecksums(t *testing.T) {
+	r := require.New(t)
+
+	db := &mockedDBConnection{}
+	rows := &rowsImpl{}
+
+	db.On("Query", mock.Anything, fmt.Sprintf(getRepeatableChecksumsQuery, DefaultMetaTable), mock.Anything).Return(rows, nil)
+	rows.On("Close")
+	rows.On("Scan", mock.Anything).Return(nil)
+	rows.On("Next").Return(true).Twice()
+	rows.On("Next").Return(false).Once()
+	rows.scans = []interface{}{
+		[]interface{}{"rep_views.sql", "abc"},
+		[]interface{}{"rep_functions.sql", "def"},
+	}
+
+	m := ImplModels{Db: db}
+	res, err := m.GetRepeatableChecksums()
+
+	r.NoError(err)
+	r.Equal(map[string]string{"rep_views.sql": "abc", "rep_functions.sql": "def"}, res)
+}
+
+func TestGetMigrationDurations(t *testing.T) {
+	r := require.New(t)
+
+	db := &mockedDBConnection{}
+	rows := &rowsImpl{}
+
+	db.On("Query", mock.Anything, fmt.Sprintf(getMigrationDurationsQuery, DefaultMetaTable), mock.Anything).Return(rows, nil)
+	rows.On("Close")
+	rows.On("Scan", mock.Anything).Return(nil)
+	rows.On("Next").Return(true).Twice()
+	rows.On("Next").Return(false).Once()
+	rows.scans = []interface{}{
+		[]interface{}{time.Unix(100, 0), int64(1500)},
+		[]interface{}{time.Unix(200, 0), int64(20)},
+	}
+
+	m := ImplModels{Db: db}
+	res, err := m.GetMigrationDurations()
+
+	r.NoError(err)
+	r.Equal(map[int64]time.Duration{100: 1500 * time.Millisecond, 200: 20 * time.Millisecond}, res)
+
+	db = &mockedDBConnection{}
+	db.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(&rowsImpl{}, demoError)
+
+	m = ImplModels{Db: db}
+	_, err = m.GetMigrationDurations()
+
+	r.True(errors.Is(err, demoError))
+}
+
+func TestExecuteRepeatable(t *testing.T) {
+	r := require.New(t)
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
+
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+
+	executionContext := ExecutionContext{Name: "rep_views.sql", Sql: "create or replace view v as select 1", Repeatable: true, Checksum: "abc"}
+
+	tx.On("Exec", mock.Anything, fmt.Sprintf("delete from %s where repeatable = $1;", DefaultMetaTable), []interface{}{"rep_views.sql"}).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts, repeatable, checksum) values (now(), $1, $2);", DefaultMetaTable), []interface{}{"rep_views.sql", "abc"}).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, executionContext.Sql, mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	m := ImplModels{Db: &mockConn}
+
+	r.NoError(m.Execute(executionContext))
+	tx.AssertExpectations(t)
+}
+
+func TestExecuteSeed(t *testing.T) {
+	r := require.New(t)
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
 
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+
+	executionContext := ExecutionContext{Timestamp: 10, Name: "seed_10.sql", Sql: "insert into roles values ('admin');", IsUp: true, Seed: true}
+
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts, name) values ($1, $2);", seedsTableName), []interface{}{time.Unix(10, 0), "seed_10.sql"}).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, "insert into roles values ('admin')", mock.Anything).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	m := ImplModels{Db: &mockConn}
+
+	r.NoError(m.Execute(executionContext))
+	tx.AssertExpectations(t)
+}
+
+func TestExecuteStatements(t *testing.T) {
+	r := require.New(t)
+
+	progress := make([]StatementProgress, 0)
+
+	executionContext := ExecutionContext{
+		Timestamp: 10,
+		Name:      "mig_10_up.sql",
+		Sql:       "create table a(id int);\n\ncreate function f() returns int as $$ select 1; $$ language sql;\nselect f();",
+		IsUp:      true,
+		Progress: func(p StatementProgress) {
+			p.Duration = 0
+			progress = append(progress, p)
+		},
+	}
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
+
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+	expectMetaSchema(&tx)
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable), mock.Anything).Return(pgconn.CommandTag{}, nil)
+	tx.On("Exec", mock.Anything, "create table a(id int)", mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, "create function f() returns int as $$ select 1; $$ language sql", mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, "select f()", mock.Anything).Return(pgconn.CommandTag{}, demoError).Once()
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	m := ImplModels{Db: &mockConn}
+
+	err := m.Execute(executionContext)
+	r.Error(err)
+	r.True(errors.Is(err, demoError))
+	r.Contains(err.Error(), "statement 3 at line 4 failed")
+
+	r.Equal([]StatementProgress{
+		{Index: 1, Total: 3, Line: 1, Statement: "create table a(id int)"},
+		{Index: 2, Total: 3, Line: 3, Statement: "create function f() returns int as $$ select 1; $$ language sql"},
+	}, progress)
+
+	tx.AssertNotCalled(t, "Commit", mock.Anything)
+}
+
+func TestExecuteCopyFromStdin(t *testing.T) {
+	r := require.New(t)
+
+	executionContext := ExecutionContext{
+		Timestamp: 10,
+		Name:      "mig_10_up.sql",
+		Sql:       "create table a(id int, name text);\nCOPY public.a (id, name) FROM stdin;\n1\tfirst; row\n2\t\\N\n\\.\nselect 1;\n",
+		IsUp:      true,
+	}
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
+
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+	expectMetaSchema(&tx)
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable), mock.Anything).Return(pgconn.CommandTag{}, nil)
+	tx.On("Exec", mock.Anything, "create table a(id int, name text)", mock.Anything).Return(pgconn.CommandTag{}, nil)
+	tx.On("Exec", mock.Anything, "select 1", mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, durationQuery, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	original := copyFromStdin
+	defer func() { copyFromStdin = original }()
+
+	copied := make([]string, 0)
+	copyFromStdin = func(ctx context.Context, copyTx pgx.Tx, sql string, data string) error {
+		r.Equal(&tx, copyTx)
+		copied = append(copied, sql, data)
+		return nil
+	}
+
+	m := ImplModels{Db: &mockConn}
+
+	r.NoError(m.Execute(executionContext))
+	r.Equal([]string{"COPY public.a (id, name) FROM stdin", "1\tfirst; row\n2\t\\N\n"}, copied)
+	tx.AssertExpectations(t)
+
+	copyFromStdin = func(ctx context.Context, copyTx pgx.Tx, sql string, data string) error {
+		return demoError
+	}
+
+	err := m.Execute(executionContext)
+	r.Error(err)
+	r.True(errors.Is(err, demoError))
+	r.Contains(err.Error(), "statement 2 at line 2 failed")
+}
+
+func TestExecuteGoMigration(t *testing.T) {
+	r := require.New(t)
+
+	mockConn := mockedDBConnection{}
+	tx := txImpl{}
+
+	mockConn.On("Begin", mock.Anything).Return(&tx, nil)
+	expectMetaSchema(&tx)
+
+	type spanKey struct{}
+
+	var received pgx.Tx
+	var receivedCtx context.Context
+	executionContext := ExecutionContext{
+		Timestamp: 123,
+		Name:      "go:123_backfill_up",
+		IsUp:      true,
+		Func: func(ctx context.Context, tx pgx.Tx) error {
+			received = tx
+			receivedCtx = ctx
+			return nil
+		},
+		Context: context.WithValue(context.Background(), spanKey{}, "migration span"),
+	}
+
+	tx.On("Exec", mock.Anything, fmt.Sprintf("insert into %s (ts) values ($1);", DefaultMetaTable), []interface{}{time.Unix(123, 0)}).
+		Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Exec", mock.Anything, durationQuery, mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	tx.On("Commit", mock.Anything).Return(nil).Once()
+	tx.On("Rollback", mock.Anything).Return(nil)
+
+	m := ImplModels{Db: &mockConn}
+
+	r.NoError(m.Execute(executionContext))
+	r.Equal(&tx, received, "go migration should receive migration transaction")
+	r.Equal("migration span", receivedCtx.Value(spanKey{}), "go migration should receive context of execution")
+	tx.AssertExpectations(t)
+}
+
+func TestExecuteHook(t *testing.T) {
+	r := require.New(t)
+
+	db := &mockedDBConnection{}
+	db.On("Exec", mock.Anything, "refresh materialized view stats;", mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+	db.On("Exec", mock.Anything, "notify x;", mock.Anything).Return(pgconn.CommandTag{}, demoError).Once()
+
+	m := ImplModels{Db: db}
+
+	r.NoError(m.ExecuteHook("refresh materialized view stats;"))
+	r.True(errors.Is(m.ExecuteHook("notify x;"), demoError))
+
+	db.AssertExpectations(t)
+}
+
+func TestIsMissingTable(t *testing.T) {
+	table := []struct {
+		name    string
+		err     error
+		missing bool
+	}{
+		{name: "undefined table", err: &pgconn.PgError{Code: "42P01"}, missing: true},
+		{name: "missing schema", err: fmt.Errorf("db error: %w", &pgconn.PgError{Code: "3F000"}), missing: true},
+		{name: "other database error", err: &pgconn.PgError{Code: "42501"}},
+		{name: "not a database error", err: demoError},
+		{name: "no error"},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.missing, IsMissingTable(test.err))
 		})
 	}
 }
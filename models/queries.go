@@ -1,12 +1,124 @@
 package models
 
+// createMetaTableQuery creates meta table with all columns. Tables created by older
+// versions are brought up to date with upgradeMetaTableQuery.
 var createMetaTableQuery = `
-	create table if not exists %s (
+	create table if not exists %[1]s (
 		id serial primary key,
-		ts timestamptz not null
+		ts timestamptz not null,
+		repeatable text,
+		checksum text,
+		duration_ms bigint
+	);
+`
+
+// upgradeMetaTableQuery adds columns to meta table created by older versions. Columns are
+// added together so it's enough to check the last one to avoid locking the table on every run.
+var upgradeMetaTableQuery = `
+	do $pgmig$
+	begin
+		if to_regclass('%[1]s') is not null and not exists (
+			select 1 from pg_attribute
+			where attrelid = to_regclass('%[1]s') and attname = 'duration_ms' and not attisdropped
+		) then
+			alter table %[1]s add column if not exists repeatable text;
+			alter table %[1]s add column if not exists checksum text;
+			alter table %[1]s add column if not exists duration_ms bigint;
+		end if;
+	end
+	$pgmig$;
+`
+
+// metaTableSchemaQuery returns schema of the table that given (unqualified) name resolves to
+var metaTableSchemaQuery = `
+	select quote_ident(n.nspname) from pg_class c join pg_namespace n on n.oid = c.relnamespace
+	where c.oid = $1::text::regclass
+`
+
+// metaTableOutdatedQuery returns whether meta table exists but lacks columns added by upgradeMetaTableQuery
+var metaTableOutdatedQuery = `
+	select to_regclass('%[1]s') is not null and not exists (
+		select 1 from pg_attribute
+		where attrelid = to_regclass('%[1]s') and attname = 'duration_ms' and not attisdropped
 	)
 `
 
 var getMigrationsListQuery = `
+	select ts from %s where repeatable is null order by ts asc
+`
+
+var appliedCheckQuery = `do $pgmig$
+declare
+	applied bigint[];
+begin
+	select coalesce(array_agg(extract(epoch from ts)::bigint order by ts), '{}') into applied
+	from %[1]s where repeatable is null;
+
+	if applied <> array[%[2]s]::bigint[] then
+		raise exception 'pg-mig: applied migrations %% differ from the ones this script was generated against', applied;
+	end if;
+end
+$pgmig$;`
+
+var getMigrationDurationsQuery = `
+	select ts, duration_ms from %s where repeatable is null and duration_ms is not null
+`
+
+var getRepeatableChecksumsQuery = `
+	select repeatable, checksum from %s where repeatable is not null
+`
+
+// getSchemaSnapshotQuery lists schema objects except meta and seeds tables (formatted as
+// first and second argument) together with their columns, indexes, constraints and id sequences
+var getSchemaSnapshotQuery = `
+	select 'table ' || table_schema || '.' || table_name || ' ' || table_type
+	from information_schema.tables
+	where table_schema not in ('pg_catalog', 'information_schema') and table_name not in ('%[1]s', '%[2]s')
+	union all
+	select 'column ' || table_schema || '.' || table_name || '.' || column_name || ' ' || data_type ||
+		' nullable=' || is_nullable || ' default=' || coalesce(column_default, '')
+	from information_schema.columns
+	where table_schema not in ('pg_catalog', 'information_schema') and table_name not in ('%[1]s', '%[2]s')
+	union all
+	select 'index ' || schemaname || '.' || indexname || ' ' || indexdef
+	from pg_indexes
+	where schemaname not in ('pg_catalog', 'information_schema') and tablename not in ('%[1]s', '%[2]s')
+	union all
+	select 'constraint ' || n.nspname || '.' || c.conname || ' ' || pg_get_constraintdef(c.oid)
+	from pg_constraint c join pg_namespace n on n.oid = c.connamespace
+	where n.nspname not in ('pg_catalog', 'information_schema') and
+		c.conrelid not in (select oid from pg_class where relname in ('%[1]s', '%[2]s'))
+	union all
+	select 'sequence ' || sequence_schema || '.' || sequence_name || ' ' || data_type
+	from information_schema.sequences
+	where sequence_schema not in ('pg_catalog', 'information_schema') and
+		sequence_name not in ('%[1]s_id_seq', '%[2]s_id_seq')
+	union all
+	select 'view ' || schemaname || '.' || viewname || ' ' || definition
+	from pg_views
+	where schemaname not in ('pg_catalog', 'information_schema')
+	union all
+	select 'function ' || n.nspname || '.' || p.proname || '(' || pg_get_function_identity_arguments(p.oid) || ') ' ||
+		md5(pg_get_functiondef(p.oid))
+	from pg_proc p join pg_namespace n on n.oid = p.pronamespace
+	where n.nspname not in ('pg_catalog', 'information_schema') and p.prokind in ('f', 'p')
+	union all
+	select 'trigger ' || event_object_schema || '.' || event_object_table || '.' || trigger_name || ' ' ||
+		action_timing || ' ' || event_manipulation || ' ' || action_statement
+	from information_schema.triggers
+	where event_object_schema not in ('pg_catalog', 'information_schema')
+	order by 1
+`
+
+var createSeedsTableQuery = `
+	create table if not exists %s (
+		id serial primary key,
+		ts timestamptz not null,
+		name text not null,
+		applied_at timestamptz not null default now()
+	)
+`
+
+var getSeedsListQuery = `
 	select ts from %s order by ts asc
 `
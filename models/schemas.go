@@ -0,0 +1,46 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListSchemas returns schema names returned by the given query in the first column
+func (models *ImplModels) ListSchemas(query string) ([]string, error) {
+	rows, err := models.Db.Query(context.Background(), query)
+	if err != nil {
+		return nil, fmt.Errorf("db error: unable to query for schemas %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]string, 0, 10)
+
+	for rows.Next() {
+		var schema string
+
+		err = rows.Scan(&schema)
+		if err != nil {
+			return result, fmt.Errorf("db error: unable to scan schema name %w", err)
+		}
+
+		result = append(result, schema)
+	}
+
+	return result, nil
+}
+
+// InSchema sets search_path of the connection to the given schema and returns
+// models that keep meta table within that schema
+func (models *ImplModels) InSchema(schema string) (Models, error) {
+	meta, err := NewMetaTable(schema, models.Meta.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = models.Db.Exec(context.Background(), fmt.Sprintf("set search_path to %s;", schema))
+	if err != nil {
+		return nil, fmt.Errorf("db error: unable to set search_path to %s %w", schema, err)
+	}
+
+	return &ImplModels{Db: models.Db, Meta: meta}, nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestListSchemas(t *testing.T) {
+	r := require.New(t)
+
+	query := "select nspname from pg_namespace where nspname like 'tenant_%'"
+
+	db := &mockedDBConnection{}
+	rows := &rowsImpl{}
+
+	db.On("Query", mock.Anything, query, mock.Anything).Return(rows, nil)
+	rows.On("Close")
+	rows.On("Scan", mock.Anything).Return(nil)
+	rows.On("Next").Return(true).Twice()
+	rows.On("Next").Return(false).Once()
+	rows.scans = []interface{}{"tenant_a", "tenant_b"}
+
+	m := ImplModels{Db: db}
+	res, err := m.ListSchemas(query)
+
+	r.NoError(err)
+	r.Equal([]string{"tenant_a", "tenant_b"}, res)
+}
+
+func TestInSchema(t *testing.T) {
+	r := require.New(t)
+
+	db := &mockedDBConnection{}
+	db.On("Exec", mock.Anything, "set search_path to tenant_a;", mock.Anything).Return(pgconn.CommandTag{}, nil).Once()
+
+	m := ImplModels{Db: db, Meta: MetaTable{Schema: "admin", Name: "billing"}}
+
+	tenant, err := m.InSchema("tenant_a")
+	r.NoError(err)
+	r.Equal(&ImplModels{Db: db, Meta: MetaTable{Schema: "tenant_a", Name: "billing"}}, tenant)
+
+	_, err = m.InSchema("tenant_a; drop table users")
+	r.Error(err)
+
+	db.AssertExpectations(t)
+}
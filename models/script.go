@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/splitter"
+	"strings"
+)
+
+// CreateMetaTableScript returns SQL statements creating (or upgrading) meta table the same way CreateMetaTable does
+func CreateMetaTableScript(meta MetaTable) string {
+	query := strings.TrimSpace(fmt.Sprintf(createMetaTableQuery, meta)) + "\n" + strings.TrimSpace(fmt.Sprintf(upgradeMetaTableQuery, meta))
+	if meta.Schema == "" {
+		return query
+	}
+
+	return fmt.Sprintf("create schema if not exists %s;\n%s", meta.Schema, query)
+}
+
+// Script returns SQL script equivalent to running migration with Execute,
+// including meta table changes, wrapped in a transaction
+func Script(executionContext ExecutionContext, meta MetaTable) (string, error) {
+	if executionContext.Hook {
+		return hookScript(executionContext), nil
+	}
+
+	body, err := stepBody(executionContext, meta)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("-- %s\nBEGIN;\n%sCOMMIT;\n", executionContext.Name, body), nil
+}
+
+// StepScript returns SQL of migration together with meta table changes without
+// transaction control so it can be embedded into a larger transaction
+func StepScript(executionContext ExecutionContext, meta MetaTable) (string, error) {
+	if executionContext.Hook {
+		return hookScript(executionContext), nil
+	}
+
+	body, err := stepBody(executionContext, meta)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("-- %s\n%s", executionContext.Name, body), nil
+}
+
+// hookScript returns SQL of a hook without transaction control and meta table changes
+func hookScript(executionContext ExecutionContext) string {
+	return fmt.Sprintf("-- %s hook\n%s\n", executionContext.Name, strings.TrimSpace(splitter.Terminate(executionContext.Sql)))
+}
+
+func stepBody(executionContext ExecutionContext, meta MetaTable) (string, error) {
+	if executionContext.Func != nil {
+		return "", fmt.Errorf("db error: go migration %s can't be represented as SQL", executionContext.Name)
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString(metaTableScript(executionContext, meta))
+	builder.WriteString("\n")
+
+	sql := strings.TrimSpace(splitter.Terminate(executionContext.Sql))
+	if sql != "" {
+		builder.WriteString(sql)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
+
+// AppliedCheckScript returns SQL block that raises an exception when migrations applied
+// in database differ from the given ones
+func AppliedCheckScript(applied []int64, meta MetaTable) string {
+	values := make([]string, 0, len(applied))
+	for _, ts := range applied {
+		values = append(values, fmt.Sprintf("%d", ts))
+	}
+
+	return fmt.Sprintf(appliedCheckQuery, meta, strings.Join(values, ","))
+}
+
+// metaTableScript returns statements with inlined values that update meta table same as updateMetaTable
+func metaTableScript(executionContext ExecutionContext, meta MetaTable) string {
+	ts := fmt.Sprintf("to_timestamp(%d)", executionContext.Timestamp)
+
+	if executionContext.Repeatable {
+		return fmt.Sprintf(
+			"delete from %s where repeatable = %s;\ninsert into %s (ts, repeatable, checksum) values (now(), %s, %s);",
+			meta,
+			quoteLiteral(executionContext.Name),
+			meta,
+			quoteLiteral(executionContext.Name),
+			quoteLiteral(executionContext.Checksum),
+		)
+	}
+
+	if executionContext.Seed {
+		return fmt.Sprintf("insert into %s (ts, name) values (%s, %s);", meta.seedsTable(), ts, quoteLiteral(executionContext.Name))
+	}
+
+	if executionContext.IsUp {
+		return fmt.Sprintf("insert into %s (ts) values (%s);", meta, ts)
+	}
+
+	return fmt.Sprintf("delete from %s where ts = %s and repeatable is null;", meta, ts)
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		name             string
+		executionContext ExecutionContext
+		expected         string
+		returnError      bool
+	}{
+		{
+			name:             "up migration",
+			executionContext: ExecutionContext{Timestamp: 123, Name: "mig_123_up.sql", Sql: "create table a();\n", IsUp: true},
+			expected:         fmt.Sprintf("-- mig_123_up.sql\nBEGIN;\ninsert into %s (ts) values (to_timestamp(123));\ncreate table a();\nCOMMIT;\n", DefaultMetaTable),
+		},
+		{
+			name:             "last statement without semicolon",
+			executionContext: ExecutionContext{Timestamp: 123, Name: "mig_123_up.sql", Sql: "create table a();\ncreate table b()\n", IsUp: true},
+			expected:         fmt.Sprintf("-- mig_123_up.sql\nBEGIN;\ninsert into %s (ts) values (to_timestamp(123));\ncreate table a();\ncreate table b();\nCOMMIT;\n", DefaultMetaTable),
+		},
+		{
+			name:             "empty down migration",
+			executionContext: ExecutionContext{Timestamp: 123, Name: "mig_123_down.sql", Sql: ""},
+			expected:         fmt.Sprintf("-- mig_123_down.sql\nBEGIN;\ndelete from %s where ts = to_timestamp(123) and repeatable is null;\nCOMMIT;\n", DefaultMetaTable),
+		},
+		{
+			name:             "repeatable migration",
+			executionContext: ExecutionContext{Name: "rep_it's.sql", Sql: "select 1;", IsUp: true, Repeatable: true, Checksum: "abc"},
+			expected: fmt.Sprintf(
+				"-- rep_it's.sql\nBEGIN;\ndelete from %s where repeatable = 'rep_it''s.sql';\ninsert into %s (ts, repeatable, checksum) values (now(), 'rep_it''s.sql', 'abc');\nselect 1;\nCOMMIT;\n",
+				DefaultMetaTable,
+				DefaultMetaTable,
+			),
+		},
+		{
+			name:             "seed",
+			executionContext: ExecutionContext{Timestamp: 5, Name: "seed_5.sql", Sql: "insert into a values (1);", IsUp: true, Seed: true},
+			expected:         fmt.Sprintf("-- seed_5.sql\nBEGIN;\ninsert into %s (ts, name) values (to_timestamp(5), 'seed_5.sql');\ninsert into a values (1);\nCOMMIT;\n", seedsTableName),
+		},
+		{
+			name:             "hook",
+			executionContext: ExecutionContext{Name: "after_each", Sql: "notify migrations, 'up'", Hook: true},
+			expected:         "-- after_each hook\nnotify migrations, 'up';\n",
+		},
+		{
+			name: "go migration",
+			executionContext: ExecutionContext{Timestamp: 5, Name: "go:5_backfill_up", IsUp: true, Func: func(ctx context.Context, tx pgx.Tx) error {
+				return nil
+			}},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := Script(test.executionContext, MetaTable{})
+			if test.returnError {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(test.expected, res)
+		})
+	}
+}
+
+func TestAppliedCheckScript(t *testing.T) {
+	r := require.New(t)
+
+	res := AppliedCheckScript([]int64{10, 20}, MetaTable{})
+	r.NotContains(res, "%!", "query is not formatted properly")
+	r.Equal(`do $pgmig$
+declare
+	applied bigint[];
+begin
+	select coalesce(array_agg(extract(epoch from ts)::bigint order by ts), '{}') into applied
+	from `+DefaultMetaTable+` where repeatable is null;
+
+	if applied <> array[10,20]::bigint[] then
+		raise exception 'pg-mig: applied migrations % differ from the ones this script was generated against', applied;
+	end if;
+end
+$pgmig$;`, res)
+
+	res = AppliedCheckScript([]int64{}, MetaTable{})
+	r.Contains(res, "if applied <> array[]::bigint[] then")
+}
@@ -19,14 +19,57 @@ type DBConnection interface {
 // Models interface for interaction with database
 type Models interface {
 	CreateMetaTable() error
+	UpgradeMetaTable() error
+	MetaTableOutdated() (bool, error)
 	GetMigrationsList() ([]int64, error)
 	Execute(ExecutionContext) error
 	SquashMigrations(time.Time, time.Time, int64) error
+	MarkMigrations([]int64) error
+	CreateDatabase(string) error
+	DropDatabase(string) error
+	GetSchemaSnapshot() ([]string, error)
+	GetRepeatableChecksums() (map[string]string, error)
+	GetMigrationDurations() (map[int64]time.Duration, error)
+	CreateSeedsTable() error
+	GetSeedsList() ([]int64, error)
+	ListSchemas(query string) ([]string, error)
+	InSchema(schema string) (Models, error)
+	ExecuteHook(sql string) error
 }
 
 type ExecutionContext struct {
-	Sql       string
-	IsUp      bool
+	Sql        string
+	IsUp       bool
+	Timestamp  int64
+	Name       string
+	Repeatable bool
+	Checksum   string
+	Seed       bool
+	// Hook marks SQL of a hook file. It runs outside of migration transactions and doesn't change meta table.
+	Hook bool
+	Func MigrationFunc
+	// Context passed to Func, for example with span of the migration. Background context is used when nil.
+	Context context.Context
+	// Progress is called after each executed statement of SQL migration when set
+	Progress func(progress StatementProgress)
+}
+
+// StatementProgress reported after a statement of migration has been executed
+type StatementProgress struct {
+	Index     int
+	Total     int
+	Line      int
+	Statement string
+	Duration  time.Duration
+}
+
+// MigrationFunc migration step implemented in Go that runs within migration transaction
+type MigrationFunc func(ctx context.Context, tx pgx.Tx) error
+
+// GoMigration migration registered from application code instead of SQL files
+type GoMigration struct {
 	Timestamp int64
 	Name      string
+	Up        MigrationFunc
+	Down      MigrationFunc
 }
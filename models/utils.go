@@ -2,23 +2,133 @@ package models
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v4"
 )
 
-const reconnectCount = 3
-const reconnectTimeout = 3
+// maxBackoff upper limit of the delay between two connection attempts
+const maxBackoff = 30 * time.Second
 
-func BuildConnector(ctx context.Context, str string) (conn DBConnection, err error) {
-	for i := 0; i < reconnectCount; i++ {
-		conn, err := pgx.Connect(ctx, str)
+// RetryPolicy controls how connecting on database is retried
+type RetryPolicy struct {
+	// Attempts maximum number of connection attempts, zero means no limit
+	Attempts int
+	// Backoff delay after the first failed attempt, doubled after each next one up to 30s
+	Backoff time.Duration
+	// MaxWait total time spent connecting, zero means no limit
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy used when retry policy is not configured
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: 3 * time.Second}
+
+// sleep waits for given duration or until context is done
+var sleep = func(ctx context.Context, duration time.Duration) error {
+	t := time.NewTimer(duration)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BuildConnector connects on database without logging using DefaultRetryPolicy
+func BuildConnector(ctx context.Context, str string) (DBConnection, error) {
+	return NewConnector(nil)(ctx, str, DefaultRetryPolicy)
+}
+
+// NewConnector returns function connecting on database which logs connection attempts
+// and every query issued over the connection with logger on debug level. Connecting is
+// retried according to given retry policy.
+func NewConnector(logger *slog.Logger) func(ctx context.Context, str string, policy RetryPolicy) (DBConnection, error) {
+	return func(ctx context.Context, str string, policy RetryPolicy) (DBConnection, error) {
+		config, err := pgx.ParseConfig(str)
+		if err != nil {
+			return nil, fmt.Errorf("db error: invalid connection string %w", err)
+		}
+
+		if logger != nil {
+			config.Logger = &queryLogger{logger: logger}
+		}
+
+		return connect(ctx, config, policy, logger)
+	}
+}
+
+func connect(ctx context.Context, config *pgx.ConnConfig, policy RetryPolicy, logger *slog.Logger) (DBConnection, error) {
+	if policy.MaxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.MaxWait)
+		defer cancel()
+	}
+
+	backoff := policy.Backoff
+	attempt := 0
+
+	var lastErr error
+	for policy.Attempts == 0 || attempt < policy.Attempts {
+		attempt++
+
+		if logger != nil {
+			logger.Debug("connecting to database", "host", config.Host, "port", config.Port, "database", config.Database, "attempt", attempt)
+		}
+
+		conn, err := pgx.ConnectConfig(ctx, config)
 		if err == nil {
-			return conn, err
+			return conn, nil
+		}
+
+		lastErr = err
+
+		if logger != nil {
+			logger.Warn("connection attempt failed", "attempt", attempt, "error", err)
+		}
+
+		if policy.Attempts != 0 && attempt >= policy.Attempts {
+			break
+		}
+
+		if ctx.Err() != nil || sleep(ctx, backoff) != nil {
+			break
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
 		}
+	}
+
+	return nil, fmt.Errorf("db error: unable to connect on %s:%d after %d attempts %w", config.Host, config.Port, attempt, lastErr)
+}
+
+// queryLogger passes pgx logs of queries issued over connection to slog logger on debug level
+type queryLogger struct {
+	logger *slog.Logger
+}
+
+func (l *queryLogger) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	if !l.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		time.Sleep(reconnectTimeout * time.Second)
+	args := make([]interface{}, 0, len(keys)*2+2)
+	args = append(args, "pgx_level", level.String())
+	for _, key := range keys {
+		args = append(args, key, data[key])
 	}
 
-	return
+	l.logger.Log(ctx, slog.LevelDebug, msg, args...)
 }
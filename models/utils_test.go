@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLogger(t *testing.T) {
+	r := require.New(t)
+
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	l := queryLogger{logger: logger}
+	l.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{
+		"sql":  "select ts from __pg_mig_meta order by ts;",
+		"time": time.Millisecond,
+	})
+
+	r.Contains(out.String(), `level=DEBUG msg=Query pgx_level=info sql="select ts from __pg_mig_meta order by ts;" time=1ms`)
+
+	out.Reset()
+	quiet := queryLogger{logger: slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}))}
+	quiet.Log(context.Background(), pgx.LogLevelInfo, "Query", map[string]interface{}{"sql": "select 1"})
+
+	r.Empty(out.String(), "queries should be logged only on debug level")
+}
+
+func TestNewConnectorInvalidConnectionString(t *testing.T) {
+	r := require.New(t)
+
+	_, err := NewConnector(nil)(context.Background(), "postgres://u:p@localhost:port/db", DefaultRetryPolicy)
+	r.Error(err)
+	r.Contains(err.Error(), "db error: invalid connection string")
+}
+
+func TestNewConnectorRetryPolicy(t *testing.T) {
+	// Nothing listens on port 1 so every attempt is refused
+	const unreachable = "postgres://u:p@127.0.0.1:1/db?sslmode=disable&connect_timeout=1"
+
+	table := []struct {
+		name     string
+		policy   *RetryPolicy
+		attempts int
+		sleeps   []time.Duration
+	}{
+		{
+			name:     "default policy",
+			attempts: 3,
+			sleeps:   []time.Duration{3 * time.Second, 6 * time.Second},
+		},
+		{
+			name:     "single attempt",
+			policy:   &RetryPolicy{Attempts: 1, Backoff: time.Second},
+			attempts: 1,
+			sleeps:   []time.Duration{},
+		},
+		{
+			name:     "backoff is limited",
+			policy:   &RetryPolicy{Attempts: 4, Backoff: 20 * time.Second},
+			attempts: 4,
+			sleeps:   []time.Duration{20 * time.Second, 30 * time.Second, 30 * time.Second},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			sleeps := []time.Duration{}
+			original := sleep
+			sleep = func(ctx context.Context, duration time.Duration) error {
+				sleeps = append(sleeps, duration)
+				return nil
+			}
+			defer func() { sleep = original }()
+
+			policy := DefaultRetryPolicy
+			if test.policy != nil {
+				policy = *test.policy
+			}
+
+			var out bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+			conn, err := NewConnector(logger)(context.Background(), unreachable, policy)
+			r.Nil(conn)
+			r.Error(err)
+			r.Contains(err.Error(), fmt.Sprintf("db error: unable to connect on 127.0.0.1:1 after %d attempts", test.attempts))
+			r.Equal(test.sleeps, sleeps)
+			r.Equal(test.attempts, strings.Count(out.String(), "connection attempt failed"))
+		})
+	}
+}
+
+func TestNewConnectorMaxWait(t *testing.T) {
+	r := require.New(t)
+
+	policy := RetryPolicy{Backoff: 10 * time.Millisecond, MaxWait: 200 * time.Millisecond}
+
+	start := time.Now()
+	_, err := NewConnector(nil)(context.Background(), "postgres://u:p@127.0.0.1:1/db?sslmode=disable", policy)
+
+	r.Error(err)
+	r.Contains(err.Error(), "db error: unable to connect on 127.0.0.1:1")
+	r.Less(int64(time.Since(start)), int64(5*time.Second), "retrying without attempts limit should stop after max wait")
+}
@@ -0,0 +1,337 @@
+package splitter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Statement single SQL statement of a migration file
+type Statement struct {
+	// Text of the statement without the terminating semicolon
+	Text string
+	// Line on which statement starts (1-based)
+	Line int
+	// CopyData rows following COPY ... FROM stdin statement, terminated with newline
+	CopyData string
+}
+
+var copyFromStdinRegex = regexp.MustCompile(`(?is)^copy\b.*\bfrom\s+stdin\b`)
+
+var dollarTagRegex = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+// IsCopyFromStdin returns whether statement reads its data from stdin
+func (statement Statement) IsCopyFromStdin() bool {
+	return copyFromStdinRegex.MatchString(statement.Text)
+}
+
+// scanner state while splitting SQL
+type scanner struct {
+	sql   string
+	pos   int
+	line  int
+	start int
+	// startLine line of the first character of the current statement
+	startLine int
+	// hasContent whether the current statement has anything besides whitespace and comments
+	hasContent bool
+	// atomicDepth nesting level inside BEGIN ATOMIC ... END body
+	atomicDepth int
+	lastWord    string
+	result      []Statement
+	// unterminated whether the last statement is not followed by semicolon
+	unterminated bool
+	// trailingComment whether comment follows the last content of the current statement
+	trailingComment bool
+}
+
+// Split splits SQL into statements. Semicolons inside string literals, quoted identifiers,
+// dollar quoted bodies, comments and BEGIN ATOMIC ... END bodies don't terminate statements.
+// Data rows of COPY ... FROM stdin (terminated with \. line) are attached to the COPY statement.
+func Split(sql string) []Statement {
+	return split(sql).result
+}
+
+// Terminate appends semicolon to SQL when its last statement is not terminated with one
+func Terminate(sql string) string {
+	s := split(sql)
+	if !s.unterminated {
+		return sql
+	}
+
+	// Semicolon can't be appended to the same line when statement is followed by a comment
+	if s.trailingComment {
+		return strings.TrimRightFunc(sql, unicode.IsSpace) + "\n;"
+	}
+
+	return strings.TrimRightFunc(sql, unicode.IsSpace) + ";"
+}
+
+func split(sql string) *scanner {
+	s := &scanner{sql: sql, line: 1, result: make([]Statement, 0)}
+
+	for s.pos < len(s.sql) {
+		c := s.sql[s.pos]
+
+		switch {
+		case c == '\n':
+			s.line++
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case c == '-' && s.peek(1) == '-':
+			s.skipLineComment()
+			s.trailingComment = true
+		case c == '/' && s.peek(1) == '*':
+			s.skipBlockComment()
+			s.trailingComment = true
+		case c == '\'':
+			s.markContent()
+			s.skipString(s.isEscapeString())
+		case c == '"':
+			s.markContent()
+			s.skipQuoted('"')
+		case c == '$' && !s.previousIsWordChar():
+			s.markContent()
+			s.skipDollarQuoted()
+		case c == ';' && s.atomicDepth == 0:
+			s.endStatement()
+		case isWordChar(c):
+			s.markContent()
+			s.readWord()
+		default:
+			s.markContent()
+			s.pos++
+		}
+	}
+
+	_, s.unterminated = s.appendStatement(s.sql[s.start:])
+
+	return s
+}
+
+func (s *scanner) peek(offset int) byte {
+	if s.pos+offset >= len(s.sql) {
+		return 0
+	}
+
+	return s.sql[s.pos+offset]
+}
+
+func (s *scanner) markContent() {
+	s.trailingComment = false
+
+	if s.hasContent {
+		return
+	}
+
+	s.hasContent = true
+	s.start = s.pos
+	s.startLine = s.line
+}
+
+func (s *scanner) previousIsWordChar() bool {
+	return s.pos > 0 && isWordChar(s.sql[s.pos-1])
+}
+
+// isEscapeString whether quote at current position starts E'...' string with backslash escapes
+func (s *scanner) isEscapeString() bool {
+	if s.pos == 0 || (s.sql[s.pos-1] != 'e' && s.sql[s.pos-1] != 'E') {
+		return false
+	}
+
+	return s.pos == 1 || !isWordChar(s.sql[s.pos-2])
+}
+
+func (s *scanner) advance(to int) {
+	if to > len(s.sql) {
+		to = len(s.sql)
+	}
+
+	s.line += strings.Count(s.sql[s.pos:to], "\n")
+	s.pos = to
+}
+
+func (s *scanner) skipLineComment() {
+	end := strings.IndexByte(s.sql[s.pos:], '\n')
+	if end < 0 {
+		s.pos = len(s.sql)
+		return
+	}
+
+	// New line is processed by the main loop
+	s.pos += end
+}
+
+func (s *scanner) skipBlockComment() {
+	depth := 0
+	i := s.pos
+
+	for i < len(s.sql) {
+		if strings.HasPrefix(s.sql[i:], "/*") {
+			depth++
+			i += 2
+			continue
+		}
+
+		if strings.HasPrefix(s.sql[i:], "*/") {
+			depth--
+			i += 2
+			if depth == 0 {
+				break
+			}
+			continue
+		}
+
+		i++
+	}
+
+	s.advance(i)
+}
+
+func (s *scanner) skipString(backslashEscapes bool) {
+	i := s.pos + 1
+
+	for i < len(s.sql) {
+		if backslashEscapes && s.sql[i] == '\\' {
+			i += 2
+			continue
+		}
+
+		if s.sql[i] == '\'' {
+			if i+1 < len(s.sql) && s.sql[i+1] == '\'' {
+				i += 2
+				continue
+			}
+
+			i++
+			break
+		}
+
+		i++
+	}
+
+	s.advance(i)
+}
+
+func (s *scanner) skipQuoted(quote byte) {
+	i := s.pos + 1
+
+	for i < len(s.sql) {
+		if s.sql[i] == quote {
+			if i+1 < len(s.sql) && s.sql[i+1] == quote {
+				i += 2
+				continue
+			}
+
+			i++
+			break
+		}
+
+		i++
+	}
+
+	s.advance(i)
+}
+
+func (s *scanner) skipDollarQuoted() {
+	tag := dollarTagRegex.FindString(s.sql[s.pos:])
+	if tag == "" {
+		// Positional parameter or a lone dollar sign
+		s.pos++
+		return
+	}
+
+	end := strings.Index(s.sql[s.pos+len(tag):], tag)
+	if end < 0 {
+		s.advance(len(s.sql))
+		return
+	}
+
+	s.advance(s.pos + len(tag) + end + len(tag))
+}
+
+func (s *scanner) readWord() {
+	i := s.pos
+	for i < len(s.sql) && isWordChar(s.sql[i]) {
+		i++
+	}
+
+	word := strings.ToLower(s.sql[s.pos:i])
+	s.pos = i
+
+	switch {
+	case s.lastWord == "begin" && word == "atomic":
+		s.atomicDepth++
+	case s.atomicDepth > 0 && word == "case":
+		s.atomicDepth++
+	case s.atomicDepth > 0 && word == "end":
+		s.atomicDepth--
+	}
+
+	s.lastWord = word
+}
+
+func (s *scanner) endStatement() {
+	text := s.sql[s.start:s.pos]
+	s.pos++
+
+	statement, ok := s.appendStatement(text)
+	if ok && statement.IsCopyFromStdin() {
+		s.readCopyData()
+	}
+
+	s.start = s.pos
+}
+
+func (s *scanner) appendStatement(text string) (Statement, bool) {
+	if !s.hasContent {
+		return Statement{}, false
+	}
+
+	statement := Statement{Text: strings.TrimSpace(text), Line: s.startLine}
+	s.result = append(s.result, statement)
+
+	s.hasContent = false
+	s.lastWord = ""
+	s.atomicDepth = 0
+
+	return statement, true
+}
+
+// readCopyData reads data rows following COPY ... FROM stdin statement until \. line
+func (s *scanner) readCopyData() {
+	// Data starts on the line after the statement
+	end := strings.IndexByte(s.sql[s.pos:], '\n')
+	if end < 0 {
+		s.pos = len(s.sql)
+		return
+	}
+
+	s.advance(s.pos + end + 1)
+
+	var data strings.Builder
+
+	for s.pos < len(s.sql) {
+		end = strings.IndexByte(s.sql[s.pos:], '\n')
+		if end < 0 {
+			end = len(s.sql) - s.pos
+		}
+
+		row := strings.TrimSuffix(s.sql[s.pos:s.pos+end], "\r")
+		s.advance(s.pos + end + 1)
+
+		if row == "\\." {
+			break
+		}
+
+		data.WriteString(row)
+		data.WriteString("\n")
+	}
+
+	s.result[len(s.result)-1].CopyData = data.String()
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
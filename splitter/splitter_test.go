@@ -0,0 +1,106 @@
+package splitter
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	r := require.New(t)
+
+	table := []struct {
+		name     string
+		sql      string
+		expected []Statement
+	}{
+		{
+			name:     "empty",
+			sql:      "  \n -- only comment\n/* block */",
+			expected: []Statement{},
+		},
+		{
+			name: "simple statements",
+			sql:  "create table a(id int);\n\ninsert into a values (1);\nselect 1",
+			expected: []Statement{
+				{Text: "create table a(id int)", Line: 1},
+				{Text: "insert into a values (1)", Line: 3},
+				{Text: "select 1", Line: 4},
+			},
+		},
+		{
+			name: "semicolons in strings, identifiers and comments",
+			sql:  "insert into \"a;b\" values ('x;''y', E'it\\'s;');\n-- comment; here\nselect /* ; /* nested ; */ */ 1;",
+			expected: []Statement{
+				{Text: "insert into \"a;b\" values ('x;''y', E'it\\'s;')", Line: 1},
+				{Text: "select /* ; /* nested ; */ */ 1", Line: 3},
+			},
+		},
+		{
+			name: "dollar quoted bodies",
+			sql:  "create function f() returns void as $body$\nbegin\n  delete from a;\nend;\n$body$ language plpgsql;\ndo $$ begin perform 1; end $$;",
+			expected: []Statement{
+				{Text: "create function f() returns void as $body$\nbegin\n  delete from a;\nend;\n$body$ language plpgsql", Line: 1},
+				{Text: "do $$ begin perform 1; end $$", Line: 6},
+			},
+		},
+		{
+			name: "begin atomic body",
+			sql:  "create function f(x int) returns int language sql\nbegin atomic\n  select case when x > 0 then 1 else 0 end;\n  select 2;\nend;\nselect f(1);",
+			expected: []Statement{
+				{Text: "create function f(x int) returns int language sql\nbegin atomic\n  select case when x > 0 then 1 else 0 end;\n  select 2;\nend", Line: 1},
+				{Text: "select f(1)", Line: 6},
+			},
+		},
+		{
+			name: "copy from stdin",
+			sql:  "COPY public.countries (code, name) FROM stdin;\nRS\tSerbia\nDE\tGermany; Deutschland\n\\.\nselect 1;",
+			expected: []Statement{
+				{Text: "COPY public.countries (code, name) FROM stdin", Line: 1, CopyData: "RS\tSerbia\nDE\tGermany; Deutschland\n"},
+				{Text: "select 1", Line: 5},
+			},
+		},
+		{
+			name: "positional parameters are not dollar quotes",
+			sql:  "prepare p as select $1; select 2;",
+			expected: []Statement{
+				{Text: "prepare p as select $1", Line: 1},
+				{Text: "select 2", Line: 1},
+			},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r.Equal(test.expected, Split(test.sql))
+		})
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	table := []struct {
+		name     string
+		sql      string
+		expected string
+	}{
+		{name: "terminated", sql: "select 1;\n", expected: "select 1;\n"},
+		{name: "unterminated", sql: "select 1;\nselect 2\n\n", expected: "select 1;\nselect 2;"},
+		{name: "semicolon in string", sql: "select ';'", expected: "select ';';"},
+		{name: "followed by comment", sql: "select 1 -- done", expected: "select 1 -- done\n;"},
+		{name: "only comments", sql: "-- nothing", expected: "-- nothing"},
+		{name: "empty", sql: "", expected: ""},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, Terminate(test.sql))
+		})
+	}
+}
+
+func TestIsCopyFromStdin(t *testing.T) {
+	r := require.New(t)
+
+	r.True(Statement{Text: "copy a (id) from STDIN"}.IsCopyFromStdin())
+	r.False(Statement{Text: "copy a (id) from '/tmp/a.csv'"}.IsCopyFromStdin())
+	r.False(Statement{Text: "select 'copy a from stdin'"}.IsCopyFromStdin())
+}
@@ -16,6 +16,8 @@ func (add *Add) Run() error {
 	flagSet := flag.NewFlagSet("add", flag.ExitOnError)
 
 	name := flagSet.String("name", "", "The name of a new revision. It will be used to construct file name. Filenames will be unique even if left blank.")
+	repeatable := flagSet.Bool("repeatable", false, "Create a single repeatable migration file rep_<name>.sql that is re-applied whenever its content changes. Requires name.")
+	seed := flagSet.Bool("seed", false, "Create a seed file seed_<timestamp>_<name>.sql in seeds directory instead of migration files")
 	help := flagSet.Bool("help", false, "Prints help for add command")
 
 	err := flagSet.Parse(add.Flags)
@@ -28,18 +30,27 @@ func (add *Add) Run() error {
 		return nil
 	}
 
+	if *repeatable {
+		if *name == "" {
+			return fmt.Errorf("add command error: repeatable migration requires a name")
+		}
+
+		return add.Filesystem.CreateMigrationFile(fmt.Sprintf("rep_%s.sql", formatMigrationName(*name)), add.Config.Path)
+	}
+
 	now := add.Timer.Now()
 	ms := now.Unix()
 
-	var nameFormatted string
-	if *name != "" {
-		escapedName := strings.ReplaceAll(*name, " ", "-")
-		underlineEscaped := strings.ReplaceAll(escapedName, "_", "-")
-		nameFormatted = fmt.Sprintf("_%s", underlineEscaped)
+	if *seed {
+		var nameFormatted string
+		if *name != "" {
+			nameFormatted = fmt.Sprintf("_%s", formatMigrationName(*name))
+		}
+
+		return add.Filesystem.CreateMigrationFile(fmt.Sprintf("seed_%d%s.sql", ms, nameFormatted), add.Config.GetSeedsDir())
 	}
 
-	upName := fmt.Sprintf("mig_%d%s_up.sql", ms, nameFormatted)
-	downName := fmt.Sprintf("mig_%d%s_down.sql", ms, nameFormatted)
+	upName, downName := migrationFileNames(ms, *name)
 
 	err = add.Filesystem.CreateMigrationFile(upName, add.Config.Path)
 	if err != nil {
@@ -53,3 +64,22 @@ func (add *Add) Run() error {
 
 	return nil
 }
+
+// migrationFileNames builds up and down file names for migration created at given timestamp
+func migrationFileNames(ts int64, name string) (up string, down string) {
+	var nameFormatted string
+	if name != "" {
+		nameFormatted = fmt.Sprintf("_%s", formatMigrationName(name))
+	}
+
+	up = fmt.Sprintf("mig_%d%s_up.sql", ts, nameFormatted)
+	down = fmt.Sprintf("mig_%d%s_down.sql", ts, nameFormatted)
+
+	return
+}
+
+// formatMigrationName escapes characters that have a special meaning in migration file names
+func formatMigrationName(name string) string {
+	escapedName := strings.ReplaceAll(name, " ", "-")
+	return strings.ReplaceAll(escapedName, "_", "-")
+}
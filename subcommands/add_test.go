@@ -70,3 +70,33 @@ func TestAddRun(t *testing.T) {
 		}
 	}
 }
+
+func TestAddRepeatable(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	add := Add{
+		CommandBase: CommandBase{
+			Filesystem: &filesystem.ImplFilesystem{Fs: fs},
+			Flags:      []string{"-repeatable", "-name=user views"},
+			Timer:      timer.Timer{Now: time.Now},
+		},
+	}
+
+	err := add.Run()
+	if err != nil {
+		t.Logf("Unexpected error %v", err)
+		t.Fail()
+	}
+
+	exists, _ := afero.Exists(fs, "rep_user-views.sql")
+	if !exists {
+		t.Log("Repeatable migration file was not created")
+		t.Fail()
+	}
+
+	add.Flags = []string{"-repeatable"}
+	if add.Run() == nil {
+		t.Log("Repeatable migration without name should return error")
+		t.Fail()
+	}
+}
@@ -0,0 +1,44 @@
+package subcommands
+
+import (
+	"fmt"
+)
+
+// backupDatabase writes pg_dump backup of the database (or only of given schema when not empty)
+// into backup directory. Target is the name of the database from targets in concurrent runs,
+// empty otherwise. Returned error means that risky operation must not continue.
+func (base *CommandBase) backupDatabase(target string, schema string) error {
+	connectionString, err := base.Config.GetConnectionString()
+	if err != nil {
+		return err
+	}
+
+	file, err := base.Filesystem.PrepareBackup(base.Config, backupName(base.Config.DbName, target, schema))
+	if err != nil {
+		return err
+	}
+
+	err = base.Dumper.Backup(connectionString, schema, file)
+	if err != nil {
+		return fmt.Errorf("backup error: unable to back up database, aborting %w", err)
+	}
+
+	base.Printer.PrintSuccess(fmt.Sprintf("Backup written to %s", file))
+
+	return nil
+}
+
+// backupName returns name of the backup file without timestamp. Targets often share database
+// name so target is included to keep backups of targets migrated at the same time apart.
+func backupName(dbName string, target string, schema string) string {
+	name := dbName
+	if target != "" {
+		name = fmt.Sprintf("%s_%s", target, name)
+	}
+
+	if schema != "" {
+		name = fmt.Sprintf("%s_%s", name, schema)
+	}
+
+	return name
+}
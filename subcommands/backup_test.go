@@ -0,0 +1,228 @@
+package subcommands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+var backupConfig = filesystem.Config{
+	DbName:      "shop",
+	DbURL:       "localhost",
+	Credentials: "u:p",
+	Port:        5432,
+	SSL:         "disable",
+}
+
+const backupConnString = "postgres://u:p@localhost:5432/shop?sslmode=disable"
+
+func TestRunBackup(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+
+	enabled := backupConfig
+	enabled.Backup = true
+
+	table := []struct {
+		name        string
+		config      filesystem.Config
+		flags       []string
+		backup      bool
+		backupError error
+		executed    int
+		returnError bool
+	}{
+		{
+			name:     "no backup when disabled",
+			config:   backupConfig,
+			flags:    []string{"-time=pop", "-yes"},
+			executed: 1,
+		},
+		{
+			name:     "backup before down migration",
+			config:   backupConfig,
+			flags:    []string{"-time=pop", "-yes", "-backup"},
+			backup:   true,
+			executed: 1,
+		},
+		{
+			name:     "backup enabled in config",
+			config:   enabled,
+			flags:    []string{"-time=pop", "-yes"},
+			backup:   true,
+			executed: 1,
+		},
+		{
+			name:   "backup disabled by flag",
+			config: enabled,
+			flags:  []string{"-time=pop", "-yes", "-backup=false"},
+			// Down migration is still executed
+			executed: 1,
+		},
+		{
+			name:     "no backup for safe migrations",
+			config:   enabled,
+			flags:    []string{"-time=2020-10-20T12:00:00Z"},
+			executed: 0,
+		},
+		{
+			name:        "failed backup aborts migrations",
+			config:      backupConfig,
+			flags:       []string{"-time=pop", "-yes", "-backup"},
+			backup:      true,
+			backupError: errors.New("pg_dump: connection refused"),
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create table users();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("drop table users;"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			m := mockedModels{}
+			m.On("GetMigrationsList").Return([]int64{t1.Unix()}, nil)
+			m.On("Execute", mock.Anything).Return(nil)
+
+			d := mockedDumper{}
+			d.On("Backup", backupConnString, "", "backups/shop_20201022T100400Z.dump").Return(test.backupError)
+
+			mp := mockedPrinter{}
+			mp.On("PrintDownMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+
+			run := Run{
+				CommandBase: CommandBase{
+					Config:     test.config,
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+					Dumper:     &d,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+				r.True(errors.Is(err, test.backupError))
+			} else {
+				r.NoError(err)
+			}
+
+			if test.backup {
+				d.AssertNumberOfCalls(t, "Backup", 1)
+			} else {
+				d.AssertNotCalled(t, "Backup", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			m.AssertNumberOfCalls(t, "Execute", test.executed)
+		})
+	}
+}
+
+func TestSquashBackup(t *testing.T) {
+	files := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 1600812000, Up: "mig_1600812000_up.sql", Down: "mig_1600812000_down.sql"},
+		filesystem.MigrationFile{Timestamp: 1600898400, Up: "mig_1600898400_up.sql", Down: "mig_1600898400_down.sql"},
+	}
+
+	table := []struct {
+		name        string
+		flags       []string
+		backup      bool
+		backupError error
+		squashed    bool
+	}{
+		{name: "squashes without backup", flags: []string{"-last=2"}, squashed: true},
+		{name: "backup before squash", flags: []string{"-last=2", "-backup"}, backup: true, squashed: true},
+		{name: "no backup in dry-run", flags: []string{"-last=2", "-backup", "-dry-run"}},
+		{name: "failed backup aborts squash", flags: []string{"-last=2", "-backup"}, backup: true, backupError: errors.New("err")},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			mockedFS := mockedFilesystem{}
+			mockedMod := mockedModels{}
+			d := mockedDumper{}
+
+			mockedMod.On("GetMigrationsList").Return([]int64{1600812000, 1600898400}, nil)
+			mockedFS.On("GetFileTimestamps", mock.Anything, mock.Anything).Return(files, nil)
+			mockedFS.On("PrepareBackup", backupConfig, "shop").Return("backups/shop.dump", nil)
+			mockedFS.On("Squash", files).Return(nil)
+			mockedMod.On("SquashMigrations", mock.Anything, mock.Anything, int64(1600898400)).Return(nil)
+			d.On("Backup", backupConnString, "", "backups/shop.dump").Return(test.backupError)
+
+			mp := mockedPrinter{}
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSQL", mock.Anything)
+
+			squash := Squash{
+				CommandBase: CommandBase{
+					Config:     backupConfig,
+					Filesystem: &mockedFS,
+					Flags:      test.flags,
+					Models:     &mockedMod,
+					Timer:      timer.Timer{Now: buildGetNow("2020-10-20T15:00:00Z")},
+					Printer:    &mp,
+					Dumper:     &d,
+				},
+			}
+
+			err := squash.Run()
+			if test.backupError != nil {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if test.backup {
+				d.AssertNumberOfCalls(t, "Backup", 1)
+			} else {
+				d.AssertNotCalled(t, "Backup", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			if test.squashed {
+				mockedFS.AssertNumberOfCalls(t, "Squash", 1)
+			} else {
+				mockedFS.AssertNotCalled(t, "Squash", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestBackupName(t *testing.T) {
+	table := []struct {
+		target string
+		schema string
+		name   string
+	}{
+		{name: "shop"},
+		{schema: "tenant_a", name: "shop_tenant_a"},
+		{target: "shard_1", name: "shard_1_shop"},
+		{target: "shard_1", schema: "tenant_a", name: "shard_1_shop_tenant_a"},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.name, backupName("shop", test.target, test.schema))
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Baseline structure for baseline command
+type Baseline struct {
+	CommandBase
+	isDryRun bool
+}
+
+// Run adopts an existing database by dumping its schema into an initial
+// migration and/or marking existing migrations as applied without running them
+func (baseline *Baseline) Run() error {
+	flagSet := flag.NewFlagSet("baseline", flag.ExitOnError)
+
+	dumpSchema := flagSet.Bool("dump", false, "Dump current database schema into an initial migration that is marked as applied")
+	name := flagSet.String("name", "baseline", "The name of baseline migration created by -dump. Defaults to baseline")
+	markStr := flagSet.String("mark", "", "Mark all migrations on filesystem up to given time as applied without executing them")
+	dryRun := flagSet.Bool("dry-run", false, "Print migrations that would be marked as applied without modifying workspace or database")
+	help := flagSet.Bool("help", false, "Prints help for baseline command")
+
+	err := flagSet.Parse(baseline.Flags)
+	if err != nil {
+		return fmt.Errorf("baseline command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if !*dumpSchema && *markStr == "" {
+		return fmt.Errorf("baseline command error: at least one of -dump or -mark has to be provided")
+	}
+
+	baseline.isDryRun = *dryRun
+
+	err = baseline.Models.CreateMetaTable()
+	if err != nil {
+		return err
+	}
+
+	inDB, err := baseline.Models.GetMigrationsList()
+	if err != nil {
+		return err
+	}
+
+	toMark := make([]int64, 0, 10)
+
+	if *dumpSchema {
+		ts, err := baseline.dump(*name)
+		if err != nil {
+			return err
+		}
+
+		toMark = append(toMark, ts)
+	}
+
+	if *markStr != "" {
+		mark, err := baseline.Timer.ParseTime(*markStr)
+		if err != nil {
+			return err
+		}
+
+		pending, err := baseline.getPending(mark, inDB)
+		if err != nil {
+			return err
+		}
+
+		toMark = mergeTimestamps(toMark, pending)
+	}
+
+	return baseline.mark(toMark)
+}
+
+func (baseline *Baseline) dump(name string) (int64, error) {
+	ts := baseline.Timer.Now().Unix()
+	upName, downName := migrationFileNames(ts, name)
+
+	if baseline.isDryRun {
+		baseline.Printer.PrintUpMigration(fmt.Sprintf("Creating baseline migration %s", upName))
+		return ts, nil
+	}
+
+	connectionString, err := baseline.Config.GetConnectionString()
+	if err != nil {
+		return 0, err
+	}
+
+	schema, err := baseline.Dumper.DumpSchema(connectionString)
+	if err != nil {
+		return 0, fmt.Errorf("baseline command error: unable to dump database schema %w", err)
+	}
+
+	err = baseline.Filesystem.WriteMigrationFile(upName, baseline.Config.Path, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	err = baseline.Filesystem.CreateMigrationFile(downName, baseline.Config.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	baseline.Printer.PrintSuccess(fmt.Sprintf("Created baseline migration %s", upName))
+
+	return ts, nil
+}
+
+func (baseline *Baseline) getPending(mark time.Time, inDB []int64) ([]int64, error) {
+	files, err := baseline.Filesystem.GetFileTimestamps(time.Time{}, mark)
+	if err != nil {
+		return nil, err
+	}
+
+	executedMap := make(map[int64]bool)
+	for _, mig := range inDB {
+		executedMap[mig] = true
+	}
+
+	pending := make([]int64, 0, len(files))
+	for _, file := range files {
+		if !executedMap[file.Timestamp] {
+			pending = append(pending, file.Timestamp)
+		}
+	}
+
+	return pending, nil
+}
+
+func (baseline *Baseline) mark(toMark []int64) error {
+	if len(toMark) == 0 {
+		baseline.Printer.PrintSuccess("No migrations to mark as applied")
+		return nil
+	}
+
+	for _, ts := range toMark {
+		baseline.Printer.PrintUpMigration(fmt.Sprintf("Marking migration %d as applied", ts))
+	}
+
+	if baseline.isDryRun {
+		return nil
+	}
+
+	return baseline.Models.MarkMigrations(toMark)
+}
+
+// mergeTimestamps returns sorted union of two timestamp lists
+func mergeTimestamps(a []int64, b []int64) []int64 {
+	seen := make(map[int64]bool)
+	result := make([]int64, 0, len(a)+len(b))
+
+	for _, list := range [][]int64{a, b} {
+		for _, ts := range list {
+			if !seen[ts] {
+				seen[ts] = true
+				result = append(result, ts)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
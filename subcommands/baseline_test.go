@@ -0,0 +1,131 @@
+package subcommands
+
+import (
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBaselineRun(t *testing.T) {
+	now := "2020-10-22T10:04:00Z"
+	tNow, _ := time.Parse(time.RFC3339, now)
+	mark, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	config := filesystem.Config{
+		Credentials: "postgres:pg_pass",
+		DbName:      "main_db",
+		DbURL:       "localhost",
+		Port:        5432,
+		Path:        "workspace",
+		SSL:         "disable",
+	}
+
+	files := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 10, Up: "mig_10_up.sql", Down: "mig_10_down.sql"},
+		filesystem.MigrationFile{Timestamp: 20, Up: "mig_20_up.sql", Down: "mig_20_down.sql"},
+		filesystem.MigrationFile{Timestamp: 30, Up: "mig_30_up.sql", Down: "mig_30_down.sql"},
+	}
+
+	table := []struct {
+		name        string
+		flags       []string
+		inDB        []int64
+		withDump    bool
+		withFiles   bool
+		marked      []int64
+		returnError bool
+	}{
+		{
+			name:        "requires dump or mark",
+			flags:       []string{},
+			returnError: true,
+		},
+		{
+			name:     "dumps schema and marks baseline",
+			flags:    []string{"-dump"},
+			withDump: true,
+			marked:   []int64{tNow.Unix()},
+		},
+		{
+			name:      "marks pending migrations",
+			flags:     []string{"-mark=2020-10-21T10:00:00Z"},
+			inDB:      []int64{20},
+			withFiles: true,
+			marked:    []int64{10, 30},
+		},
+		{
+			name:      "dumps and marks",
+			flags:     []string{"-dump", "-mark=2020-10-21T10:00:00Z"},
+			withDump:  true,
+			withFiles: true,
+			marked:    []int64{10, 20, 30, tNow.Unix()},
+		},
+		{
+			name:      "dry run does not mark",
+			flags:     []string{"-mark=2020-10-21T10:00:00Z", "-dry-run"},
+			withFiles: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			models := mockedModels{}
+			fs := mockedFilesystem{}
+			dumper := mockedDumper{}
+			mp := mockedPrinter{}
+
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+
+			inDB := test.inDB
+			if inDB == nil {
+				inDB = []int64{}
+			}
+			if !test.returnError {
+				models.On("GetMigrationsList").Return(inDB, nil)
+			}
+
+			if test.withDump {
+				dumper.On("DumpSchema", mock.Anything).Return("create table t();", nil).Once()
+				fs.On("WriteMigrationFile", "mig_1603361040_baseline_up.sql", "workspace", "create table t();").
+					Return(nil).Once()
+			}
+
+			if test.withFiles {
+				fs.On("GetFileTimestamps", time.Time{}, mark).Return(files, nil).Once()
+			}
+
+			if test.marked != nil {
+				models.On("MarkMigrations", test.marked).Return(nil).Once()
+			}
+
+			baseline := Baseline{
+				CommandBase: CommandBase{
+					Config:     config,
+					Models:     &models,
+					Filesystem: &fs,
+					Dumper:     &dumper,
+					Printer:    &mp,
+					Flags:      test.flags,
+					Timer:      timer.Timer{Now: buildGetNow(now)},
+				},
+			}
+
+			err := baseline.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			models.AssertExpectations(t)
+			fs.AssertExpectations(t)
+			dumper.AssertExpectations(t)
+		})
+	}
+}
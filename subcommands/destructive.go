@@ -0,0 +1,110 @@
+package subcommands
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/djordjev/pg-mig/guard"
+	"github.com/djordjev/pg-mig/models"
+	"io"
+	"strings"
+)
+
+// guarded collects steps that apply would execute and, when some of them are
+// down migrations or destructive statements, requires confirmation (and creates
+// a backup when enabled) before executing them for real
+func (run *Run) guarded(apply func() error) error {
+	if run.isDryRun || run.collect != nil {
+		return apply()
+	}
+
+	steps := make([]models.ExecutionContext, 0)
+
+	printer := run.Printer
+	run.Printer = &discardPrinter{}
+	run.collect = func(execContext models.ExecutionContext) error {
+		steps = append(steps, execContext)
+		return nil
+	}
+
+	err := apply()
+
+	run.Printer = printer
+	run.collect = nil
+	run.executed = 0
+
+	if err != nil {
+		return err
+	}
+
+	warnings, hasDown := destructiveWarnings(steps)
+
+	err = run.confirmDestructive(warnings, hasDown)
+	if err != nil {
+		return err
+	}
+
+	if run.backupFirst && len(warnings) > 0 {
+		err = run.backupDatabase(run.targetName, run.tenantSchema)
+		if err != nil {
+			return err
+		}
+	}
+
+	return apply()
+}
+
+// destructiveWarnings describes down migrations and destructive statements among steps
+func destructiveWarnings(steps []models.ExecutionContext) (warnings []string, hasDown bool) {
+	warnings = make([]string, 0)
+
+	for _, step := range steps {
+		if !step.IsUp && !step.Hook {
+			hasDown = true
+			warnings = append(warnings, fmt.Sprintf("%s is a down migration", step.Name))
+		}
+
+		for _, finding := range guard.Scan(step.Sql) {
+			warnings = append(warnings, fmt.Sprintf("%s %s: %s", step.Name, finding.Reason, finding.Statement))
+		}
+	}
+
+	return
+}
+
+// newAnswers returns reader of interactive confirmations, nil when input is not available
+func newAnswers(input io.Reader) *bufio.Reader {
+	if input == nil {
+		return nil
+	}
+
+	return bufio.NewReader(input)
+}
+
+// confirmDestructive returns error unless destructive steps are allowed by flag
+// or confirmed interactively. Down migrations are never allowed in protected environment.
+func (run *Run) confirmDestructive(warnings []string, hasDown bool) error {
+	if hasDown && run.Config.IsProtected() {
+		return fmt.Errorf("run command error: down migrations are forbidden in protected environment %s", run.Config.Environment)
+	}
+
+	if len(warnings) == 0 || run.allowDestructive {
+		return nil
+	}
+
+	for _, warning := range warnings {
+		run.Printer.PrintError(warning)
+	}
+
+	if run.answers == nil {
+		return fmt.Errorf("run command error: destructive changes require confirmation, use -yes or -allow-destructive flag")
+	}
+
+	run.Printer.PrintError("Type yes to execute destructive changes:")
+
+	answer, _ := run.answers.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+		return fmt.Errorf("run command error: destructive changes were not confirmed")
+	}
+
+	return nil
+}
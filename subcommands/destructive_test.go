@@ -0,0 +1,157 @@
+package subcommands
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunDestructiveGuard(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	table := []struct {
+		name        string
+		inDB        []int64
+		config      filesystem.Config
+		flags       []string
+		input       io.Reader
+		allow       bool
+		executed    int
+		returnError bool
+	}{
+		{
+			name:     "safe migration runs without confirmation",
+			inDB:     []int64{},
+			flags:    []string{"-time=2020-10-20T12:00:00Z"},
+			executed: 1,
+		},
+		{
+			name:        "destructive migration requires confirmation",
+			inDB:        []int64{t1.Unix()},
+			flags:       []string{},
+			returnError: true,
+		},
+		{
+			name:     "destructive migration confirmed interactively",
+			inDB:     []int64{t1.Unix()},
+			flags:    []string{},
+			input:    strings.NewReader("yes\n"),
+			executed: 1,
+		},
+		{
+			name:        "destructive migration rejected interactively",
+			inDB:        []int64{t1.Unix()},
+			flags:       []string{},
+			input:       strings.NewReader("no\n"),
+			returnError: true,
+		},
+		{
+			name:     "destructive migration allowed by flag",
+			inDB:     []int64{t1.Unix()},
+			flags:    []string{"-allow-destructive"},
+			executed: 1,
+		},
+		{
+			name:     "destructive migration allowed by library",
+			inDB:     []int64{t1.Unix()},
+			flags:    []string{},
+			allow:    true,
+			executed: 1,
+		},
+		{
+			name:        "down migration requires confirmation",
+			inDB:        []int64{t1.Unix()},
+			flags:       []string{"-time=pop"},
+			returnError: true,
+		},
+		{
+			name:        "down migration forbidden in protected environment",
+			inDB:        []int64{t1.Unix()},
+			config:      filesystem.Config{Environment: "production", Protected: []string{"production"}},
+			flags:       []string{"-time=pop", "-yes"},
+			returnError: true,
+		},
+		{
+			name:     "down migration allowed in other environment",
+			inDB:     []int64{t1.Unix()},
+			config:   filesystem.Config{Environment: "staging", Protected: []string{"production"}},
+			flags:    []string{"-time=pop", "-yes"},
+			executed: 1,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create table users();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("select 1;"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte("drop table sessions;"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("create table sessions();"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			m := mockedModels{}
+			m.On("GetMigrationsList").Return(test.inDB, nil)
+			m.On("Execute", mock.Anything).Return(nil)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintDownMigration", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+
+			run := Run{
+				CommandBase: CommandBase{
+					Config:     test.config,
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+					Input:      test.input,
+
+					AllowDestructive: test.allow,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			m.AssertNumberOfCalls(t, "Execute", test.executed)
+		})
+	}
+}
+
+func TestConfirmDestructiveSharedAnswers(t *testing.T) {
+	r := require.New(t)
+
+	mp := mockedPrinter{}
+	mp.On("PrintError", mock.Anything)
+
+	run := Run{CommandBase: CommandBase{Printer: &mp, Input: strings.NewReader("yes\nyes\n")}}
+	run.answers = newAnswers(run.Input)
+
+	// Each tenant is migrated by a copy of run and asks for its own confirmation
+	for _, schema := range []string{"tenant_a", "tenant_b"} {
+		tenant := run
+		tenant.tenantSchema = schema
+
+		r.NoError(tenant.confirmDestructive([]string{"drop table users"}, false))
+	}
+}
@@ -9,10 +9,19 @@ func (help *Help) Run() error {
 	fmt.Println("Commands:")
 	fmt.Println("init -> initializes pg-mig with a database to run migrations against")
 	fmt.Println("add -> adds new migration files with current timestamp associated")
+	fmt.Println("lint -> checks migration files for operations that cause downtime on Postgres")
 	fmt.Println("log -> prints available migrations in database and on filesystem")
 	fmt.Println("run -> executes migrations for given time")
+	fmt.Println("plan -> writes SQL script with all steps run would execute for given time")
+	fmt.Println("baseline -> adopts an existing database by dumping its schema and/or marking migrations as applied")
+	fmt.Println("redo -> rolls back and re-applies the last applied migrations")
+	fmt.Println("restore -> restores database from a backup created before down migrations or squash")
+	fmt.Println("seed -> applies seed data that has not been applied yet in current environment")
 	fmt.Println("squash -> merges (squashes) multiple migrations into one")
+	fmt.Println("unsquash -> restores original migrations of a squashed migration from archive")
+	fmt.Println("wait -> blocks until database is reachable, useful in container entrypoints")
 	fmt.Println()
+	fmt.Println("Global flags (passed before the command): -v verbose, -q quiet, -log-format text|json")
 	fmt.Println("Note: for more info and flags run pg-mig command -help (for example pg-mig init -help)")
 	return nil
 }
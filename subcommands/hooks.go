@@ -0,0 +1,120 @@
+package subcommands
+
+import (
+	"context"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+)
+
+// HookContext metadata of the migration a hook is invoked for.
+// It's empty for before_all and after_all hooks.
+type HookContext struct {
+	Name      string
+	Timestamp int64
+	IsUp      bool
+}
+
+// HookFunc callback invoked around migrations
+type HookFunc func(ctx context.Context, hook HookContext) error
+
+// Hooks Go callbacks invoked around migrations after SQL hook files with the same name
+type Hooks struct {
+	BeforeAll  []HookFunc
+	AfterAll   []HookFunc
+	BeforeEach []HookFunc
+	AfterEach  []HookFunc
+}
+
+func (hooks Hooks) get(name string) []HookFunc {
+	switch name {
+	case filesystem.HookBeforeAll:
+		return hooks.BeforeAll
+	case filesystem.HookAfterAll:
+		return hooks.AfterAll
+	case filesystem.HookBeforeEach:
+		return hooks.BeforeEach
+	case filesystem.HookAfterEach:
+		return hooks.AfterEach
+	}
+
+	return nil
+}
+
+// runHook executes SQL hook file and Go callbacks registered under given name.
+// Hooks are not executed in dry-run mode or while steps are only collected, SQL of
+// the hook file is collected (or printed in dry-run) in place of its execution instead.
+func (run *Run) runHook(name string, hookContext HookContext) error {
+	if run.isDryRun && run.collect == nil && !run.printSQL {
+		return nil
+	}
+
+	if run.hookFiles == nil {
+		hookFiles, err := run.Filesystem.GetHooks()
+		if err != nil {
+			return err
+		}
+
+		run.hookFiles = hookFiles
+	}
+
+	if content, ok := run.hookFiles[name]; ok {
+		metadata := map[string]string{}
+		if hookContext.Name != "" {
+			metadata["name"] = hookContext.Name
+			metadata["timestamp"] = fmt.Sprintf("%d", hookContext.Timestamp)
+			metadata["direction"] = "down"
+			if hookContext.IsUp {
+				metadata["direction"] = "up"
+			}
+		}
+
+		sql, err := filesystem.RenderHook(name, content, run.Config, metadata)
+		if err != nil {
+			return err
+		}
+
+		err = run.applyHook(models.ExecutionContext{Name: name, Sql: sql, Hook: true})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Go callbacks have no SQL representation
+	if run.isDryRun || run.collect != nil {
+		return nil
+	}
+
+	for _, hook := range run.Hooks.get(name) {
+		err := hook(run.traceContext(), hookContext)
+		if err != nil {
+			return fmt.Errorf("run command error: hook %s failed %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyHook executes SQL of hook file, collects it when steps are only collected or prints it in dry-run
+func (run *Run) applyHook(hook models.ExecutionContext) error {
+	if run.collect != nil {
+		return run.collect(hook)
+	}
+
+	if run.isDryRun {
+		script, err := models.Script(hook, run.Meta)
+		if err != nil {
+			return err
+		}
+
+		run.Printer.PrintSQL(script)
+		return nil
+	}
+
+	err := run.Models.ExecuteHook(hook.Sql)
+	if err != nil {
+		return fmt.Errorf("run command error: hook %s failed %w", hook.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHooks(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	up1 := fmt.Sprintf("mig_%d_up.sql", t1.Unix())
+	up2 := fmt.Sprintf("mig_%d_up.sql", t2.Unix())
+
+	table := []struct {
+		name        string
+		flags       []string
+		hookError   error
+		expected    []string
+		returnError bool
+	}{
+		{
+			name:  "runs hooks around migrations",
+			flags: []string{},
+			expected: []string{
+				"sql:select 'before all';",
+				"sql:mig_1_up_sql",
+				"sql:notify migrations, 'up " + up1 + "';",
+				"go:after_each " + up1,
+				"sql:mig_2_up_sql",
+				"sql:notify migrations, 'up " + up2 + "';",
+				"go:after_each " + up2,
+				"go:after_all",
+			},
+		},
+		{
+			name:        "failing hook stops run",
+			flags:       []string{},
+			hookError:   errors.New("hook error"),
+			expected:    []string{"sql:select 'before all';", "sql:mig_1_up_sql", "sql:notify migrations, 'up " + up1 + "';", "go:after_each " + up1},
+			returnError: true,
+		},
+		{
+			name:     "dry run skips hooks",
+			flags:    []string{"-dry-run"},
+			expected: []string{},
+		},
+		{
+			name:  "dry run prints hooks with sql",
+			flags: []string{"-dry-run", "-sql"},
+			expected: []string{
+				"print:-- before_all hook\nselect 'before all';\n",
+				"print:" + up1,
+				"print:-- after_each hook\nnotify migrations, 'up " + up1 + "';\n",
+				"print:" + up2,
+				"print:-- after_each hook\nnotify migrations, 'up " + up2 + "';\n",
+			},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, up1, []byte("mig_1_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, up2, []byte("mig_2_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("mig_2_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, "before_all.sql", []byte("select 'before all';"), os.ModePerm)
+			_ = afero.WriteFile(fs, "after_each.sql", []byte("notify migrations, '{{.direction}} {{.name}}';"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			calls := make([]string, 0)
+
+			m := mockedModels{}
+			m.On("GetMigrationsList").Return([]int64{}, nil)
+			m.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+				calls = append(calls, "sql:"+args.Get(0).(models.ExecutionContext).Sql)
+			}).Return(nil)
+			m.On("ExecuteHook", mock.Anything).Run(func(args mock.Arguments) {
+				calls = append(calls, "sql:"+args.String(0))
+			}).Return(nil)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintSQL", mock.Anything).Run(func(args mock.Arguments) {
+				sql := args.String(0)
+				if strings.HasPrefix(sql, "-- mig_") {
+					// Only name of the migration, its SQL is covered by tests of dry run
+					sql = strings.SplitN(sql[3:], "\n", 2)[0]
+				}
+				calls = append(calls, "print:"+sql)
+			})
+
+			run := Run{
+				CommandBase: CommandBase{
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+					Hooks: Hooks{
+						AfterEach: []HookFunc{func(ctx context.Context, hook HookContext) error {
+							calls = append(calls, "go:after_each "+hook.Name)
+							return test.hookError
+						}},
+						AfterAll: []HookFunc{func(ctx context.Context, hook HookContext) error {
+							calls = append(calls, "go:after_all")
+							return nil
+						}},
+					},
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			r.Equal(test.expected, calls)
+		})
+	}
+}
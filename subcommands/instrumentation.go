@@ -0,0 +1,149 @@
+package subcommands
+
+import (
+	"context"
+	"time"
+
+	"github.com/djordjev/pg-mig/models"
+)
+
+// Names of spans and attributes reported to Tracer
+const (
+	SpanRun       = "pg-mig.run"
+	SpanMigration = "pg-mig.migration"
+
+	AttributeMigrationName      = "pgmig.migration.name"
+	AttributeMigrationDirection = "pgmig.migration.direction"
+	AttributeMigrationTimestamp = "pgmig.migration.timestamp"
+)
+
+// Attribute key-value pair attached to span. Value is a string, int64 or bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span traced unit of work started by Tracer
+type Span interface {
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans around the whole run and each executed migration.
+// It's usually implemented on top of OpenTelemetry tracer of the host application.
+type Tracer interface {
+	Start(ctx context.Context, name string, attributes []Attribute) (context.Context, Span)
+}
+
+// Metrics receives measurements of executed migrations. It's usually implemented
+// with Prometheus collectors registered by the host application. Target is the name
+// of migrated target or tenant schema so that it can be used as a label when several
+// databases are migrated at once. It's empty in regular runs.
+type Metrics interface {
+	// MigrationApplied is called after migration has been executed successfully
+	MigrationApplied(target string, name string, direction string, duration time.Duration)
+	// MigrationFailed is called when migration fails
+	MigrationFailed(target string, name string, direction string)
+	// SchemaVersion is called at the end of successful run with timestamp of the last
+	// applied migration, zero when there are none
+	SchemaVersion(target string, timestamp int64)
+}
+
+// traceContext returns context of the current run span
+func (run *Run) traceContext() context.Context {
+	if run.spanContext == nil {
+		return context.Background()
+	}
+
+	return run.spanContext
+}
+
+// metricsTarget returns name of the target or tenant schema that is migrated, empty in regular runs
+func (run *Run) metricsTarget() string {
+	if run.targetName != "" {
+		return run.targetName
+	}
+
+	return run.tenantSchema
+}
+
+// startRunSpan starts span around the whole run. Returned function ends it recording given error.
+func (run *Run) startRunSpan() func(err error) {
+	if run.Tracer == nil {
+		return func(err error) {}
+	}
+
+	ctx, span := run.Tracer.Start(run.traceContext(), SpanRun, nil)
+	parent := run.spanContext
+	run.spanContext = ctx
+
+	return func(err error) {
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		span.End()
+		run.spanContext = parent
+	}
+}
+
+// instrumented executes migration within its own span and reports its outcome to metrics.
+// Execute receives context of the migration span.
+func (run *Run) instrumented(execContext models.ExecutionContext, execute func(ctx context.Context) (time.Duration, error)) error {
+	direction := "down"
+	if execContext.IsUp {
+		direction = "up"
+	}
+
+	ctx := run.traceContext()
+
+	var span Span
+	if run.Tracer != nil {
+		ctx, span = run.Tracer.Start(ctx, SpanMigration, []Attribute{
+			{Key: AttributeMigrationName, Value: execContext.Name},
+			{Key: AttributeMigrationDirection, Value: direction},
+			{Key: AttributeMigrationTimestamp, Value: execContext.Timestamp},
+		})
+	}
+
+	duration, err := execute(ctx)
+
+	if span != nil {
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		span.End()
+	}
+
+	if run.Metrics != nil {
+		if err != nil {
+			run.Metrics.MigrationFailed(run.metricsTarget(), execContext.Name, direction)
+		} else {
+			run.Metrics.MigrationApplied(run.metricsTarget(), execContext.Name, direction, duration)
+		}
+	}
+
+	return err
+}
+
+// reportSchemaVersion reports the last applied migration to metrics
+func (run *Run) reportSchemaVersion() error {
+	if run.Metrics == nil {
+		return nil
+	}
+
+	inDB, err := run.Models.GetMigrationsList()
+	if err != nil {
+		return err
+	}
+
+	var version int64
+	if len(inDB) > 0 {
+		version = inDB[len(inDB)-1]
+	}
+
+	run.Metrics.SchemaVersion(run.metricsTarget(), version)
+
+	return nil
+}
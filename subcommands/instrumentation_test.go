@@ -0,0 +1,290 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+type spanKey struct{}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes []Attribute
+	err        error
+	ended      bool
+}
+
+func (span *recordedSpan) RecordError(err error) {
+	span.err = err
+}
+
+func (span *recordedSpan) End() {
+	span.ended = true
+}
+
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (tracer *recordingTracer) Start(ctx context.Context, name string, attributes []Attribute) (context.Context, Span) {
+	span := &recordedSpan{name: name, attributes: attributes}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		span.parent = parent.name
+	}
+
+	tracer.spans = append(tracer.spans, span)
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+type recordingMetrics struct {
+	applied []string
+	failed  []string
+	// targets labels passed with each applied or failed migration
+	targets []string
+	// versions reported schema versions keyed by target
+	versions map[string]int64
+}
+
+func (metrics *recordingMetrics) MigrationApplied(target string, name string, direction string, duration time.Duration) {
+	metrics.applied = append(metrics.applied, fmt.Sprintf("%s %s %s", direction, name, duration))
+	metrics.targets = append(metrics.targets, target)
+}
+
+func (metrics *recordingMetrics) MigrationFailed(target string, name string, direction string) {
+	metrics.failed = append(metrics.failed, fmt.Sprintf("%s %s", direction, name))
+	metrics.targets = append(metrics.targets, target)
+}
+
+func (metrics *recordingMetrics) SchemaVersion(target string, timestamp int64) {
+	if metrics.versions == nil {
+		metrics.versions = make(map[string]int64)
+	}
+
+	metrics.versions[target] = timestamp
+}
+
+// appliedModels records executed migrations so that list of migrations in DB changes during the run
+type appliedModels struct {
+	mockedModels
+	applied []int64
+	// spans names of spans from contexts migrations were executed with
+	spans []string
+}
+
+func (m *appliedModels) GetMigrationsList() ([]int64, error) {
+	return append([]int64{}, m.applied...), nil
+}
+
+func (m *appliedModels) Execute(executionContext models.ExecutionContext) error {
+	if executionContext.Context != nil {
+		if span, ok := executionContext.Context.Value(spanKey{}).(*recordedSpan); ok {
+			m.spans = append(m.spans, span.name)
+		}
+	}
+
+	err := m.mockedModels.Execute(executionContext)
+	if err == nil {
+		m.applied = append(m.applied, executionContext.Timestamp)
+	}
+
+	return err
+}
+
+func TestRunInstrumentation(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	name1 := fmt.Sprintf("mig_%d_up.sql", t1.Unix())
+	name2 := fmt.Sprintf("mig_%d_up.sql", t2.Unix())
+
+	executeError := errors.New("relation already exists")
+
+	table := []struct {
+		name         string
+		flags        []string
+		executeError error
+		spans        []string
+		applied      []string
+		failed       []string
+		version      int64
+		returnError  bool
+	}{
+		{
+			name:    "instruments executed migrations",
+			flags:   []string{},
+			spans:   []string{SpanRun, SpanMigration, SpanMigration},
+			applied: []string{"up " + name1 + " 10s", "up " + name2 + " 10s"},
+			version: t2.Unix(),
+		},
+		{
+			name:         "records failed migration",
+			flags:        []string{},
+			executeError: executeError,
+			spans:        []string{SpanRun, SpanMigration},
+			failed:       []string{"up " + name1},
+			returnError:  true,
+		},
+		{
+			name:  "nothing is executed in dry-run",
+			flags: []string{"-dry-run"},
+			spans: []string{SpanRun},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, name1, []byte("create table a();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("select 1;"), os.ModePerm)
+			_ = afero.WriteFile(fs, name2, []byte("create table b();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("select 1;"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			// Every reading of the clock moves it 10 seconds forward
+			now, _ := time.Parse(time.RFC3339, "2020-10-22T10:04:00Z")
+			getNow := func() time.Time {
+				now = now.Add(10 * time.Second)
+				return now
+			}
+
+			m := appliedModels{}
+			m.On("Execute", mock.Anything).Return(test.executeError)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+
+			tracer := recordingTracer{}
+			metrics := recordingMetrics{}
+
+			run := Run{
+				CommandBase: CommandBase{
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+					Tracer:     &tracer,
+					Metrics:    &metrics,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			names := make([]string, 0, len(tracer.spans))
+			for i, span := range tracer.spans {
+				names = append(names, span.name)
+				r.True(span.ended)
+
+				if i > 0 {
+					r.Equal(SpanRun, span.parent)
+				}
+			}
+			r.Equal(test.spans, names)
+			r.ElementsMatch(test.spans[1:], m.spans, "migrations should be executed within their spans")
+
+			if test.returnError {
+				r.Equal(test.executeError, tracer.spans[0].err)
+				r.Equal(test.executeError, tracer.spans[len(tracer.spans)-1].err)
+			}
+
+			r.Equal(test.applied, metrics.applied)
+			r.Equal(test.failed, metrics.failed)
+			if !test.returnError && len(test.applied) > 0 {
+				r.Equal(map[string]int64{"": test.version}, metrics.versions)
+			} else {
+				r.Nil(metrics.versions)
+			}
+
+			for _, target := range metrics.targets {
+				r.Empty(target)
+			}
+		})
+	}
+}
+
+func TestRunMigrationSpanAttributes(t *testing.T) {
+	tracer := recordingTracer{}
+
+	run := Run{CommandBase: CommandBase{Tracer: &tracer}}
+
+	var received *recordedSpan
+
+	execContext := models.ExecutionContext{Name: "mig_1603188000_down.sql", Timestamp: 1603188000}
+	err := run.instrumented(execContext, func(ctx context.Context) (time.Duration, error) {
+		received, _ = ctx.Value(spanKey{}).(*recordedSpan)
+		return time.Second, nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, tracer.spans, 1)
+	require.Equal(t, tracer.spans[0], received, "migration should be executed within context of its span")
+	require.Equal(t, []Attribute{
+		{Key: AttributeMigrationName, Value: "mig_1603188000_down.sql"},
+		{Key: AttributeMigrationDirection, Value: "down"},
+		{Key: AttributeMigrationTimestamp, Value: int64(1603188000)},
+	}, tracer.spans[0].attributes)
+}
+
+func TestRunTenantMetrics(t *testing.T) {
+	r := require.New(t)
+
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create table a();"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("drop table a;"), os.ModePerm)
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+	getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+	tenantA := appliedModels{}
+	tenantA.On("Execute", mock.Anything).Return(nil)
+	tenantB := appliedModels{}
+	tenantB.On("Execute", mock.Anything).Return(nil)
+
+	m := mockedModels{}
+	m.On("InSchema", "tenant_a").Return(&tenantA, nil)
+	m.On("InSchema", "tenant_b").Return(&tenantB, nil)
+
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", mock.Anything)
+	mp.On("PrintSuccess", mock.Anything)
+
+	metrics := recordingMetrics{}
+
+	run := Run{
+		CommandBase: CommandBase{
+			Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+			Timer:      timer.Timer{Now: getNow},
+			Models:     &m,
+			Flags:      []string{"-schemas=tenant_a,tenant_b"},
+			Printer:    &mp,
+			Metrics:    &metrics,
+		},
+	}
+
+	r.NoError(run.Run())
+	r.Equal([]string{"tenant_a", "tenant_b"}, metrics.targets)
+	r.Equal(map[string]int64{"tenant_a": t1.Unix(), "tenant_b": t1.Unix()}, metrics.versions)
+}
@@ -0,0 +1,115 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/lint"
+	"time"
+)
+
+const (
+	lintFormatText = "text"
+	lintFormatJSON = "json"
+)
+
+// Lint structure for lint command
+type Lint struct {
+	CommandBase
+}
+
+// Run checks migration files for operations known to cause downtime on Postgres
+func (l *Lint) Run() error {
+	flagSet := flag.NewFlagSet("lint", flag.ExitOnError)
+
+	format := flagSet.String("format", lintFormatText, "Output format: text or json")
+	rules := flagSet.Bool("rules", false, "Prints all rules with their IDs")
+	vars := templateVars{}
+	flagSet.Var(vars, "var", "Template variable in form key=value. Can be repeated")
+	help := flagSet.Bool("help", false, "Prints help for lint command")
+
+	err := flagSet.Parse(l.Flags)
+	if err != nil {
+		return fmt.Errorf("lint command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if *format != lintFormatText && *format != lintFormatJSON {
+		return fmt.Errorf("lint command error: unknown format %s, expected %s or %s", *format, lintFormatText, lintFormatJSON)
+	}
+
+	if *rules {
+		for _, rule := range lint.Rules {
+			l.Printer.PrintSuccess(fmt.Sprintf("%s %s: %s", rule.ID, rule.Name, rule.Description))
+		}
+
+		return nil
+	}
+
+	l.Config.CliVars = vars
+
+	files, err := l.Filesystem.GetFileTimestamps(time.Time{}, l.Timer.Now())
+	if err != nil {
+		return err
+	}
+
+	findings := make([]lint.Finding, 0)
+
+	for _, file := range files {
+		for _, direction := range []filesystem.Direction{filesystem.DirectionUp, filesystem.DirectionDown} {
+			name := file.Up
+			if direction == filesystem.DirectionDown {
+				name = file.Down
+			}
+
+			// migrations without down file have nothing to check in that direction
+			if name == "" {
+				continue
+			}
+
+			content, err := l.Filesystem.ReadMigrationContent(file, direction, l.Config)
+			if err != nil {
+				return err
+			}
+
+			findings = append(findings, lint.Lint(name, content)...)
+		}
+	}
+
+	if *format == lintFormatJSON {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return fmt.Errorf("lint command error: unable to serialize findings %w", err)
+		}
+
+		l.Printer.PrintRaw(string(data))
+	} else {
+		for _, finding := range findings {
+			l.Printer.PrintError(fmt.Sprintf("%s %s %s: %s\n    %s", finding.File, finding.Rule, finding.Name, finding.Message, finding.Statement))
+		}
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("lint command error: found %d problems in %d files", len(findings), countFiles(findings))
+	}
+
+	if *format == lintFormatText {
+		l.Printer.PrintSuccess(fmt.Sprintf("No problems found in %d migrations", len(files)))
+	}
+
+	return nil
+}
+
+func countFiles(findings []lint.Finding) int {
+	files := make(map[string]bool)
+	for _, finding := range findings {
+		files[finding.File] = true
+	}
+
+	return len(files)
+}
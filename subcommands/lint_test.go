@@ -0,0 +1,145 @@
+package subcommands
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLintRun(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	table := []struct {
+		name        string
+		up          string
+		noDown      bool
+		template    bool
+		flags       []string
+		errors      []string
+		raw         string
+		returnError bool
+	}{
+		{
+			name:   "clean migrations",
+			up:     "create index concurrently a_idx on a(id);",
+			flags:  []string{},
+			errors: []string{},
+		},
+		{
+			name:        "reports findings with file and rule",
+			up:          "create index a_idx on a(id);",
+			flags:       []string{},
+			errors:      []string{fmt.Sprintf("mig_%d_up.sql PGM002 index-not-concurrently", t2.Unix())},
+			returnError: true,
+		},
+		{
+			name:        "suppressed finding",
+			up:          "-- pg-mig:lint-ignore PGM002\ncreate index a_idx on a(id);",
+			flags:       []string{},
+			errors:      []string{},
+			returnError: false,
+		},
+		{
+			name:        "json output",
+			up:          "create index a_idx on a(id);",
+			flags:       []string{"-format=json"},
+			errors:      []string{},
+			raw:         `"rule": "PGM002"`,
+			returnError: true,
+		},
+		{
+			name:   "migration without down file",
+			up:     "create index concurrently a_idx on a(id);",
+			noDown: true,
+			flags:  []string{},
+			errors: []string{},
+		},
+		{
+			name:        "renders template variables from flags",
+			up:          "create index {{.concurrently}} a_idx on a(id);",
+			template:    true,
+			flags:       []string{"-var=concurrently="},
+			errors:      []string{fmt.Sprintf("mig_%d_up.sql PGM002 index-not-concurrently", t2.Unix())},
+			returnError: true,
+		},
+		{
+			name:     "template variable suppresses finding",
+			up:       "create index {{.concurrently}} a_idx on a(id);",
+			template: true,
+			flags:    []string{"-var=concurrently=concurrently"},
+			errors:   []string{},
+		},
+		{
+			name:        "unknown format",
+			up:          "",
+			flags:       []string{"-format=xml"},
+			errors:      []string{},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create table a(id int);"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("drop table a;"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte(test.up), os.ModePerm)
+			if !test.noDown {
+				_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("drop index a_idx;"), os.ModePerm)
+			}
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			printed := make([]string, 0)
+			raw := ""
+
+			mp := mockedPrinter{}
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintError", mock.Anything).Run(func(args mock.Arguments) {
+				printed = append(printed, args.String(0))
+			})
+			mp.On("PrintRaw", mock.Anything).Run(func(args mock.Arguments) {
+				raw += args.String(0)
+			})
+
+			l := Lint{
+				CommandBase: CommandBase{
+					Config:     filesystem.Config{Template: test.template},
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Flags:      test.flags,
+					Printer:    &mp,
+				},
+			}
+
+			err := l.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			r.Len(printed, len(test.errors))
+			for i, expected := range test.errors {
+				r.True(strings.HasPrefix(printed[i], expected), "unexpected finding %s", printed[i])
+			}
+
+			if test.raw == "" {
+				r.Empty(raw)
+			} else {
+				r.Contains(raw, test.raw)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ type logGroup struct {
 	timestamp int64
 	inDB      *int64
 	onFS      *filesystem.MigrationFile
+	// duration execution time stored in meta table, nil when unknown
+	duration *time.Duration
 }
 
 // Run displays a log of currently present migrations
@@ -60,6 +62,11 @@ func (log *Log) getData() (migrations []logGroup, err error) {
 		return
 	}
 
+	durations, err := log.Models.GetMigrationDurations()
+	if err != nil {
+		return
+	}
+
 	// Create maps
 	for _, migDB := range inDBList {
 		inDB[migDB] = true
@@ -98,6 +105,10 @@ func (log *Log) getData() (migrations []logGroup, err error) {
 			group.inDB = nil
 		}
 
+		if duration, ok := durations[ts]; ok {
+			group.duration = &duration
+		}
+
 		migrations = append(migrations, group)
 	}
 
@@ -114,6 +125,10 @@ func (log *Log) printMigrations(migrations []logGroup) {
 			db = fmt.Sprintf("%d", *mig.inDB)
 		}
 
+		if mig.inDB != nil && mig.duration != nil {
+			db = fmt.Sprintf("%s (%s)", db, *mig.duration)
+		}
+
 		if mig.onFS != nil {
 			fs = mig.onFS.Up
 		}
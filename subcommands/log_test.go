@@ -33,6 +33,7 @@ func TestLog(t *testing.T) {
 		inDBErr   error
 		onFS      filesystem.MigrationFileList
 		onFSErr   error
+		durations map[int64]time.Duration
 		timerArgs []timerArgs
 	}{
 		{
@@ -49,6 +50,19 @@ func TestLog(t *testing.T) {
 				{date: t3.In(loc).Format(time.RFC3339), fs: "t3_up.sql", db: fmt.Sprintf("%d", t3.Unix())},
 			},
 		},
+		{
+			name: "prints durations",
+			inDB: []int64{t1.Unix(), t2.Unix()},
+			onFS: filesystem.MigrationFileList{
+				filesystem.MigrationFile{Timestamp: t1.Unix(), Up: "t1_up.sql"},
+				filesystem.MigrationFile{Timestamp: t2.Unix(), Up: "t2_up.sql"},
+			},
+			durations: map[int64]time.Duration{t2.Unix(): 1500 * time.Millisecond},
+			timerArgs: []timerArgs{
+				{date: t1.In(loc).Format(time.RFC3339), fs: "t1_up.sql", db: fmt.Sprintf("%d", t1.Unix())},
+				{date: t2.In(loc).Format(time.RFC3339), fs: "t2_up.sql", db: fmt.Sprintf("%d (1.5s)", t2.Unix())},
+			},
+		},
 		{
 			name: "missing second in db",
 			inDB: []int64{t1.Unix(), t3.Unix()},
@@ -91,6 +105,12 @@ func TestLog(t *testing.T) {
 			models.On("GetMigrationsList").Return(test.inDB, test.inDBErr)
 			fs.On("GetFileTimestamps", time.Time{}, tNow).Return(test.onFS, test.onFSErr)
 
+			durations := test.durations
+			if durations == nil {
+				durations = map[int64]time.Duration{}
+			}
+			models.On("GetMigrationDurations").Return(durations, nil)
+
 			for _, v := range test.timerArgs {
 				mp.On("PrintMigrations", v.date, v.fs, v.db).Once()
 			}
@@ -20,10 +20,58 @@ func (m *mockedModels) SquashMigrations(i time.Time, i2 time.Time, i3 int64) err
 	return c.Error(0)
 }
 
+func (m *mockedModels) MarkMigrations(timestamps []int64) error {
+	c := m.Called(timestamps)
+	return c.Error(0)
+}
+
+func (m *mockedModels) CreateDatabase(name string) error {
+	c := m.Called(name)
+	return c.Error(0)
+}
+
+func (m *mockedModels) DropDatabase(name string) error {
+	c := m.Called(name)
+	return c.Error(0)
+}
+
+func (m *mockedModels) GetSchemaSnapshot() ([]string, error) {
+	c := m.Called()
+	return c.Get(0).([]string), c.Error(1)
+}
+
+func (m *mockedModels) GetMigrationDurations() (map[int64]time.Duration, error) {
+	c := m.Called()
+	return c.Get(0).(map[int64]time.Duration), c.Error(1)
+}
+
+func (m *mockedModels) GetRepeatableChecksums() (map[string]string, error) {
+	c := m.Called()
+	return c.Get(0).(map[string]string), c.Error(1)
+}
+
+func (m *mockedModels) CreateSeedsTable() error {
+	c := m.Called()
+	return c.Error(0)
+}
+
+func (m *mockedModels) GetSeedsList() ([]int64, error) {
+	c := m.Called()
+	return c.Get(0).([]int64), c.Error(1)
+}
+
 func (m *mockedModels) CreateMetaTable() error {
 	return m.createMetaTableError
 }
 
+func (m *mockedModels) UpgradeMetaTable() error {
+	return nil
+}
+
+func (m *mockedModels) MetaTableOutdated() (bool, error) {
+	return false, nil
+}
+
 func (m *mockedModels) GetMigrationsList() ([]int64, error) {
 	c := m.Called()
 	return c.Get(0).([]int64), c.Error(1)
@@ -42,6 +90,7 @@ type mockedFilesystem struct {
 	createMigrationFileError  error
 	readMigrationContentRes   string
 	readMigrationContentError error
+	getHooksRes               map[string]string
 }
 
 func (m *mockedFilesystem) Squash(list filesystem.MigrationFileList) error {
@@ -49,11 +98,31 @@ func (m *mockedFilesystem) Squash(list filesystem.MigrationFileList) error {
 	return c.Error(0)
 }
 
+func (m *mockedFilesystem) GetSquashContent(list filesystem.MigrationFileList) (string, string, error) {
+	c := m.Called(list)
+	return c.String(0), c.String(1), c.Error(2)
+}
+
+func (m *mockedFilesystem) RestoreSquash(ts int64) (filesystem.MigrationFileList, error) {
+	c := m.Called(ts)
+	return c.Get(0).(filesystem.MigrationFileList), c.Error(1)
+}
+
 func (m *mockedFilesystem) DeleteMigrationFiles(list filesystem.MigrationFileList) error {
 	c := m.Called(list)
 	return c.Error(0)
 }
 
+func (m *mockedFilesystem) PrepareBackup(config filesystem.Config, name string) (string, error) {
+	c := m.Called(config, name)
+	return c.String(0), c.Error(1)
+}
+
+func (m *mockedFilesystem) GetBackups(config filesystem.Config) ([]string, error) {
+	c := m.Called(config)
+	return c.Get(0).([]string), c.Error(1)
+}
+
 func (m *mockedFilesystem) StoreConfig(_ filesystem.Config) error {
 	return m.storeConfigError
 }
@@ -62,20 +131,68 @@ func (m *mockedFilesystem) LoadConfig() (filesystem.Config, error) {
 	return m.loadConfigConfig, m.loadConfigError
 }
 
+func (m *mockedFilesystem) LoadTargets(path string) ([]filesystem.Target, error) {
+	c := m.Called(path)
+	return c.Get(0).([]filesystem.Target), c.Error(1)
+}
+
+func (m *mockedFilesystem) GetHooks() (map[string]string, error) {
+	return m.getHooksRes, nil
+}
+
 func (m *mockedFilesystem) CreateMigrationFile(_ string, _ string) error {
 	return m.createMigrationFileError
 }
 
+func (m *mockedFilesystem) WriteMigrationFile(name string, location string, content string) error {
+	c := m.Called(name, location, content)
+	return c.Error(0)
+}
+
 func (m *mockedFilesystem) ReadMigrationContent(file filesystem.MigrationFile, direction filesystem.Direction, config filesystem.Config) (string, error) {
 	c := m.Called(file, direction, config)
 	return c.String(0), c.Error(1)
 }
 
+func (m *mockedFilesystem) ReadSeedContent(file filesystem.SeedFile, config filesystem.Config) (string, error) {
+	c := m.Called(file, config)
+	return c.String(0), c.Error(1)
+}
+
+func (m *mockedFilesystem) GetRepeatableMigrations() (filesystem.RepeatableFileList, error) {
+	c := m.Called()
+	return c.Get(0).(filesystem.RepeatableFileList), c.Error(1)
+}
+
+func (m *mockedFilesystem) GetSeeds() (filesystem.SeedFileList, error) {
+	c := m.Called()
+	return c.Get(0).(filesystem.SeedFileList), c.Error(1)
+}
+
 func (m *mockedFilesystem) GetFileTimestamps(t1 time.Time, t2 time.Time) (filesystem.MigrationFileList, error) {
 	args := m.Called(t1, t2)
 	return args.Get(0).(filesystem.MigrationFileList), args.Error(1)
 }
 
+type mockedDumper struct {
+	mock.Mock
+}
+
+func (m *mockedDumper) DumpSchema(connString string) (string, error) {
+	c := m.Called(connString)
+	return c.String(0), c.Error(1)
+}
+
+func (m *mockedDumper) Backup(connString string, schema string, file string) error {
+	c := m.Called(connString, schema, file)
+	return c.Error(0)
+}
+
+func (m *mockedDumper) Restore(connString string, file string) error {
+	c := m.Called(connString, file)
+	return c.Error(0)
+}
+
 type mockedPrinter struct {
 	mock.Mock
 }
@@ -100,6 +217,33 @@ func (m *mockedPrinter) PrintMigrations(date string, onFS string, inDB string) {
 	m.Called(date, onFS, inDB)
 }
 
+func (m *mockedPrinter) PrintSQL(text string) {
+	m.Called(text)
+}
+
+func (m *mockedPrinter) PrintRaw(text string) {
+	m.Called(text)
+}
+
 func (m *mockedPrinter) SetNoColor(color bool) {
 	m.Called(color)
 }
+
+func (m *mockedModels) ListSchemas(query string) ([]string, error) {
+	c := m.Called(query)
+	return c.Get(0).([]string), c.Error(1)
+}
+
+func (m *mockedModels) InSchema(schema string) (models.Models, error) {
+	c := m.Called(schema)
+	if c.Get(0) == nil {
+		return nil, c.Error(1)
+	}
+
+	return c.Get(0).(models.Models), c.Error(1)
+}
+
+func (m *mockedModels) ExecuteHook(sql string) error {
+	c := m.Called(sql)
+	return c.Error(0)
+}
@@ -0,0 +1,102 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"github.com/djordjev/pg-mig/models"
+	"path/filepath"
+	"strings"
+)
+
+// Plan structure for plan command
+type Plan struct {
+	CommandBase
+}
+
+// Run writes every step that run command would execute for given args
+// into a single SQL script that can be reviewed and applied with psql
+func (plan *Plan) Run() error {
+	flagSet := flag.NewFlagSet("plan", flag.ExitOnError)
+
+	strTime := flagSet.String("time", "", "Time on which you want to upgrade/downgrade DB. Omit for current time")
+	out := flagSet.String("out", "", "Path of the SQL script file to write the plan into (required)")
+	offline := flagSet.Bool("offline", false, "Write a single transaction that aborts when applied migrations differ from the current ones")
+	vars := templateVars{}
+	flagSet.Var(vars, "var", "Template variable in form key=value. Can be repeated")
+	help := flagSet.Bool("help", false, "Prints help for plan command")
+
+	err := flagSet.Parse(plan.Flags)
+	if err != nil {
+		return fmt.Errorf("plan command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if *out == "" {
+		return fmt.Errorf("plan command error: output file must be provided with -out flag")
+	}
+
+	var script strings.Builder
+	script.WriteString(fmt.Sprintf("-- pg-mig plan generated at %s\n", plan.Timer.Now().UTC().Format("2006-01-02T15:04:05Z")))
+	script.WriteString("\\set ON_ERROR_STOP on\n\n")
+
+	toScript := models.Script
+
+	if *offline {
+		inDB, err := plan.Models.GetMigrationsList()
+		if err != nil {
+			return err
+		}
+
+		// Whole script runs in one transaction so it either applies everything or nothing
+		toScript = models.StepScript
+		script.WriteString("BEGIN;\n\n")
+		script.WriteString(models.CreateMetaTableScript(plan.Meta))
+		script.WriteString("\n\n")
+		script.WriteString(models.AppliedCheckScript(inDB, plan.Meta))
+		script.WriteString("\n")
+	} else {
+		script.WriteString(models.CreateMetaTableScript(plan.Meta))
+		script.WriteString("\n")
+	}
+
+	steps := 0
+
+	run := Run{CommandBase: plan.CommandBase, isDryRun: true}
+	run.Config.CliVars = vars
+	run.collect = func(execContext models.ExecutionContext) error {
+		step, err := toScript(execContext, plan.Meta)
+		if err != nil {
+			return fmt.Errorf("plan command error: %w", err)
+		}
+
+		script.WriteString("\n")
+		script.WriteString(step)
+		if !execContext.Hook {
+			steps++
+		}
+
+		return nil
+	}
+
+	err = run.migrate(strTime)
+	if err != nil {
+		return err
+	}
+
+	if *offline {
+		script.WriteString("\nCOMMIT;\n")
+	}
+
+	err = plan.Filesystem.WriteMigrationFile(filepath.Base(*out), filepath.Dir(*out), script.String())
+	if err != nil {
+		return err
+	}
+
+	plan.Printer.PrintSuccess(fmt.Sprintf("Plan with %d steps written to %s", steps, *out))
+
+	return nil
+}
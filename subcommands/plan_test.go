@@ -0,0 +1,175 @@
+package subcommands
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanRun(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+	t3, _ := time.Parse(time.RFC3339, "2020-10-22T10:00:00Z")
+
+	table := []struct {
+		name        string
+		inDB        []int64
+		flags       []string
+		contains    []string
+		missing     []string
+		returnError bool
+	}{
+		{
+			name:  "plan pending up migrations",
+			inDB:  []int64{t1.Unix()},
+			flags: []string{"-out=plans/deploy.sql"},
+			contains: []string{
+				"\\set ON_ERROR_STOP on",
+				"create table if not exists __pg_mig_meta",
+				fmt.Sprintf("-- mig_%d_up.sql\nBEGIN;\ninsert into __pg_mig_meta (ts) values (to_timestamp(%d));\nmig_2_up_sql;\nCOMMIT;\n", t2.Unix(), t2.Unix()),
+				fmt.Sprintf("-- mig_%d_up.sql\nBEGIN;\ninsert into __pg_mig_meta (ts) values (to_timestamp(%d));\nmig_3_up_sql;\nCOMMIT;\n", t3.Unix(), t3.Unix()),
+			},
+			missing: []string{"mig_1_up_sql"},
+		},
+		{
+			name:  "plan down migrations",
+			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
+			flags: []string{"-out=plans/deploy.sql", "-time=2020-10-20T12:00:00Z"},
+			contains: []string{
+				fmt.Sprintf("-- mig_%d_down.sql\nBEGIN;\ndelete from __pg_mig_meta where ts = to_timestamp(%d) and repeatable is null;\nmig_3_down_sql;\nCOMMIT;\n", t3.Unix(), t3.Unix()),
+				fmt.Sprintf("-- mig_%d_down.sql\nBEGIN;\ndelete from __pg_mig_meta where ts = to_timestamp(%d) and repeatable is null;\nmig_2_down_sql;\nCOMMIT;\n", t2.Unix(), t2.Unix()),
+			},
+			missing: []string{"mig_1_down_sql", "_up.sql"},
+		},
+		{
+			name:  "offline plan in single transaction",
+			inDB:  []int64{t1.Unix()},
+			flags: []string{"-out=plans/deploy.sql", "-offline"},
+			contains: []string{
+				"\\set ON_ERROR_STOP on\n\nBEGIN;\n\ncreate table if not exists __pg_mig_meta",
+				fmt.Sprintf("if applied <> array[%d]::bigint[] then", t1.Unix()),
+				fmt.Sprintf("-- mig_%d_up.sql\ninsert into __pg_mig_meta (ts) values (to_timestamp(%d));\nmig_2_up_sql;\n", t2.Unix(), t2.Unix()),
+				"mig_3_up_sql;\n\nCOMMIT;\n",
+			},
+			missing: []string{"BEGIN;\ninsert"},
+		},
+		{
+			name:        "missing output file",
+			inDB:        []int64{},
+			flags:       []string{},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("mig_1_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte("mig_2_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("mig_2_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t3.Unix()), []byte("mig_3_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t3.Unix()), []byte("mig_3_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			m := mockedModels{}
+			m.On("GetMigrationsList").Return(test.inDB, nil)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintDownMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+
+			plan := Plan{
+				CommandBase: CommandBase{
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+				},
+			}
+
+			err := plan.Run()
+			m.AssertNotCalled(t, "Execute", mock.Anything)
+
+			if test.returnError {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+
+			content, err := afero.ReadFile(fs, "plans/deploy.sql")
+			r.NoError(err)
+
+			for _, expected := range test.contains {
+				r.Contains(string(content), expected)
+			}
+
+			for _, unexpected := range test.missing {
+				r.False(strings.Contains(string(content), unexpected), "plan should not contain %s", unexpected)
+			}
+		})
+	}
+}
+
+func TestPlanRunWithHooks(t *testing.T) {
+	r := require.New(t)
+
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	up1 := fmt.Sprintf("mig_%d_up.sql", t1.Unix())
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, up1, []byte("mig_1_up_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, "before_all.sql", []byte("select 'before all';"), os.ModePerm)
+	_ = afero.WriteFile(fs, "before_each.sql", []byte("set lock_timeout = '5s';"), os.ModePerm)
+	_ = afero.WriteFile(fs, "after_each.sql", []byte("notify migrations, '{{.direction}} {{.name}}';"), os.ModePerm)
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+	getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+	m := mockedModels{}
+	m.On("GetMigrationsList").Return([]int64{}, nil)
+
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", mock.Anything)
+	mp.On("PrintSuccess", "Plan with 1 steps written to plans/deploy.sql").Once()
+
+	plan := Plan{
+		CommandBase: CommandBase{
+			Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+			Timer:      timer.Timer{Now: getNow},
+			Models:     &m,
+			Flags:      []string{"-out=plans/deploy.sql"},
+			Printer:    &mp,
+		},
+	}
+
+	r.NoError(plan.Run())
+	m.AssertNotCalled(t, "ExecuteHook", mock.Anything)
+	mp.AssertExpectations(t)
+
+	content, err := afero.ReadFile(fs, "plans/deploy.sql")
+	r.NoError(err)
+
+	r.Contains(string(content), fmt.Sprintf(
+		"\n-- before_all hook\nselect 'before all';\n"+
+			"\n-- before_each hook\nset lock_timeout = '5s';\n"+
+			"\n-- %s\nBEGIN;\ninsert into __pg_mig_meta (ts) values (to_timestamp(%d));\nmig_1_up_sql;\nCOMMIT;\n"+
+			"\n-- after_each hook\nnotify migrations, 'up %s';\n",
+		up1, t1.Unix(), up1,
+	))
+}
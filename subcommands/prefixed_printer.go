@@ -0,0 +1,70 @@
+package subcommands
+
+import (
+	"sync"
+)
+
+// prefixedPrinter prefixes every message with name of the target and serializes
+// printing when several targets are migrated concurrently
+type prefixedPrinter struct {
+	prefix  string
+	printer Printer
+	mutex   *sync.Mutex
+}
+
+func (p *prefixedPrinter) PrintUpMigration(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintUpMigration(p.prefix + text)
+}
+
+func (p *prefixedPrinter) PrintDownMigration(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintDownMigration(p.prefix + text)
+}
+
+func (p *prefixedPrinter) PrintError(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintError(p.prefix + text)
+}
+
+func (p *prefixedPrinter) PrintSuccess(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintSuccess(p.prefix + text)
+}
+
+func (p *prefixedPrinter) PrintMigrations(date string, onFS string, inDB string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintMigrations(p.prefix+date, onFS, inDB)
+}
+
+func (p *prefixedPrinter) PrintSQL(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintSQL(p.prefix + text)
+}
+
+// PrintRaw prints text without prefix as it's machine readable
+func (p *prefixedPrinter) PrintRaw(text string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.PrintRaw(text)
+}
+
+func (p *prefixedPrinter) SetNoColor(color bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.printer.SetNoColor(color)
+}
@@ -2,6 +2,9 @@ package subcommands
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 )
 
 const (
@@ -13,6 +16,7 @@ const (
 	colorDown    = "\033[35m"
 	colorInDB    = "\033[33m"
 	colorOnFS    = "\033[35m"
+	colorSQL     = "\033[90m"
 )
 
 type Printer interface {
@@ -21,49 +25,85 @@ type Printer interface {
 	PrintError(text string)
 	PrintSuccess(text string)
 	PrintMigrations(date string, onFS string, inDB string)
+	PrintSQL(text string)
+	// PrintRaw prints machine readable output (for example JSON) without any decoration, also in quiet mode
+	PrintRaw(text string)
 
 	SetNoColor(color bool)
 }
 
+// ImplPrinter prints human readable output. Errors are printed to ErrOut (stderr by default)
+// and everything else to Out (stdout by default). In quiet mode only errors, results
+// of log command and machine readable output are printed.
 type ImplPrinter struct {
 	NoColor bool
+	Quiet   bool
+	Out     io.Writer
+	ErrOut  io.Writer
+}
+
+func (p *ImplPrinter) out() io.Writer {
+	if p.Out == nil {
+		return os.Stdout
+	}
+
+	return p.Out
+}
+
+func (p *ImplPrinter) errOut() io.Writer {
+	if p.ErrOut == nil {
+		return os.Stderr
+	}
+
+	return p.ErrOut
 }
 
 func (p *ImplPrinter) PrintUpMigration(text string) {
+	if p.Quiet {
+		return
+	}
+
 	if p.NoColor {
-		fmt.Println(text)
+		fmt.Fprintln(p.out(), text)
 		return
 	}
 
-	fmt.Println(colorUp, "⏫ ", text, colorReset)
+	fmt.Fprintln(p.out(), colorUp, "⏫ ", text, colorReset)
 }
 
 func (p *ImplPrinter) PrintDownMigration(text string) {
+	if p.Quiet {
+		return
+	}
+
 	if p.NoColor {
-		fmt.Println(text)
+		fmt.Fprintln(p.out(), text)
 		return
 	}
 
-	fmt.Println(colorDown, "⏬ ", text, colorReset)
+	fmt.Fprintln(p.out(), colorDown, "⏬ ", text, colorReset)
 }
 
 func (p *ImplPrinter) PrintError(text string) {
 	if p.NoColor {
-		fmt.Println(text)
+		fmt.Fprintln(p.errOut(), text)
 		return
 	}
 
-	fmt.Println(colorError, "❌ ", text, colorReset)
-
+	fmt.Fprintln(p.errOut(), colorError, "❌ ", text, colorReset)
 }
 
 func (p *ImplPrinter) PrintSuccess(text string) {
+	if p.Quiet {
+		return
+	}
+
 	if p.NoColor {
-		fmt.Println(text)
+		fmt.Fprintln(p.out(), text)
 		return
 	}
 
-	fmt.Println(colorSuccess, "✔️ ", text, colorReset)
+	fmt.Fprintln(p.out(), colorSuccess, "✔️ ", text, colorReset)
 }
 
 func (p *ImplPrinter) PrintMigrations(date string, onFS string, inDB string) {
@@ -78,13 +118,84 @@ func (p *ImplPrinter) PrintMigrations(date string, onFS string, inDB string) {
 	}
 
 	if p.NoColor {
-		fmt.Println(fmt.Sprintf("%s   |   %s / %s", date, fs, db))
+		fmt.Fprintln(p.out(), fmt.Sprintf("%s   |   %s / %s", date, fs, db))
+		return
+	}
+
+	fmt.Fprintln(p.out(), date, "   |   ", colorOnFS, fs, colorReset, " / ", colorInDB, db, colorReset)
+}
+
+func (p *ImplPrinter) PrintSQL(text string) {
+	if p.Quiet {
+		return
+	}
+
+	if p.NoColor {
+		fmt.Fprintln(p.out(), text)
 		return
 	}
 
-	fmt.Println(date, "   |   ", colorOnFS, fs, colorReset, " / ", colorInDB, db, colorReset)
+	fmt.Fprintln(p.out(), colorSQL, text, colorReset)
+}
+
+func (p *ImplPrinter) PrintRaw(text string) {
+	fmt.Fprintln(p.out(), text)
 }
 
 func (p *ImplPrinter) SetNoColor(color bool) {
 	p.NoColor = color
 }
+
+// LogPrinter prints output as structured log records
+type LogPrinter struct {
+	Logger *slog.Logger
+}
+
+func (p *LogPrinter) PrintUpMigration(text string) {
+	p.Logger.Info("up migration", "migration", text)
+}
+
+func (p *LogPrinter) PrintDownMigration(text string) {
+	p.Logger.Info("down migration", "migration", text)
+}
+
+func (p *LogPrinter) PrintError(text string) {
+	p.Logger.Error(text)
+}
+
+func (p *LogPrinter) PrintSuccess(text string) {
+	p.Logger.Info(text)
+}
+
+func (p *LogPrinter) PrintMigrations(date string, onFS string, inDB string) {
+	p.Logger.Info("migration", "date", date, "fs", onFS, "db", inDB)
+}
+
+func (p *LogPrinter) PrintSQL(text string) {
+	p.Logger.Info("sql", "sql", text)
+}
+
+func (p *LogPrinter) PrintRaw(text string) {
+	p.Logger.Info("output", "output", text)
+}
+
+func (p *LogPrinter) SetNoColor(_ bool) {}
+
+// discardPrinter ignores everything that is printed
+type discardPrinter struct{}
+
+func (p *discardPrinter) PrintUpMigration(_ string) {}
+
+func (p *discardPrinter) PrintDownMigration(_ string) {}
+
+func (p *discardPrinter) PrintError(_ string) {}
+
+func (p *discardPrinter) PrintSuccess(_ string) {}
+
+func (p *discardPrinter) PrintMigrations(_ string, _ string, _ string) {}
+
+func (p *discardPrinter) PrintSQL(_ string) {}
+
+func (p *discardPrinter) PrintRaw(_ string) {}
+
+func (p *discardPrinter) SetNoColor(_ bool) {}
@@ -0,0 +1,54 @@
+package subcommands
+
+import (
+	"bytes"
+	"github.com/djordjev/pg-mig/logging"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestImplPrinter(t *testing.T) {
+	r := require.New(t)
+
+	var out, errOut bytes.Buffer
+
+	p := ImplPrinter{NoColor: true, Out: &out, ErrOut: &errOut}
+	p.PrintUpMigration("mig_1_up.sql")
+	p.PrintError("unable to connect")
+	p.PrintMigrations("2020-10-20", "mig_1", "")
+
+	r.Equal("mig_1_up.sql\n2020-10-20   |   fs:mig_1 / \n", out.String())
+	r.Equal("unable to connect\n", errOut.String())
+
+	out.Reset()
+	errOut.Reset()
+
+	p.Quiet = true
+	p.PrintUpMigration("mig_1_up.sql")
+	p.PrintSuccess("done")
+	p.PrintSQL("select 1;")
+	p.PrintError("unable to connect")
+
+	r.Empty(out.String(), "quiet printer should print only errors")
+	r.Equal("unable to connect\n", errOut.String())
+
+	p.PrintRaw(`{"rule":"PGM002"}`)
+	r.Equal("{\"rule\":\"PGM002\"}\n", out.String(), "quiet printer should print machine readable output")
+}
+
+func TestLogPrinter(t *testing.T) {
+	r := require.New(t)
+
+	var out, errOut bytes.Buffer
+
+	logger, err := logging.New(&out, &errOut, logging.Options{Level: slog.LevelInfo, Format: logging.FormatJSON})
+	r.NoError(err)
+
+	p := LogPrinter{Logger: logger}
+	p.PrintUpMigration("mig_1_up.sql")
+	p.PrintError("unable to connect")
+
+	r.Contains(out.String(), `"msg":"up migration","migration":"mig_1_up.sql"`)
+	r.Contains(errOut.String(), `"level":"ERROR","msg":"unable to connect"`)
+}
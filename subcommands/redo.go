@@ -0,0 +1,95 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"time"
+)
+
+// Redo structure for redo command
+type Redo struct {
+	CommandBase
+}
+
+// Run rolls back the last n applied migrations and applies them again
+func (redo *Redo) Run() error {
+	flagSet := flag.NewFlagSet("redo", flag.ExitOnError)
+
+	steps := flagSet.Int("n", 1, "Number of the last applied migrations to roll back and re-apply. Defaults to 1")
+	dryRun := flagSet.Bool("dry-run", false, "Run command in order to just print migrations that would be executed for given args without actually executing them.")
+	vars := templateVars{}
+	flagSet.Var(vars, "var", "Template variable in form key=value. Can be repeated")
+	yes := flagSet.Bool("yes", false, "Execute down migrations and destructive statements without confirmation")
+	allowDestructive := flagSet.Bool("allow-destructive", false, "Same as -yes")
+	backup := flagSet.Bool("backup", redo.Config.Backup, "Back up database with pg_dump before rolling back migrations")
+	help := flagSet.Bool("help", false, "Prints help for redo command")
+
+	err := flagSet.Parse(redo.Flags)
+	if err != nil {
+		return fmt.Errorf("redo command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if *steps < 1 {
+		return fmt.Errorf("redo command error: number of steps must be positive, got %d", *steps)
+	}
+
+	inDB, err := redo.Models.GetMigrationsList()
+	if err != nil {
+		return err
+	}
+
+	if len(inDB) == 0 {
+		return fmt.Errorf("redo command error: redo operation on empty db is no-op")
+	}
+
+	if *steps > len(inDB) {
+		return fmt.Errorf("redo command error: requested %d steps but only %d migrations are applied", *steps, len(inDB))
+	}
+
+	run := Run{
+		CommandBase:      redo.CommandBase,
+		isDryRun:         *dryRun,
+		allowDestructive: *yes || *allowDestructive || redo.AllowDestructive,
+		answers:          newAnswers(redo.Input),
+		backupFirst:      *backup,
+	}
+	run.Config.CliVars = vars
+
+	// Everything after border is rolled back, then applied again with current file contents
+	stayInDB := inDB[:len(inDB)-*steps]
+	border := time.Unix(inDB[len(inDB)-*steps]-1, 0)
+
+	_, files, err := run.getMigrationFiles(border)
+	if err != nil {
+		return err
+	}
+
+	downMigrations := run.getInDBDownMigrations(inDB, border)
+
+	redoMap := make(map[int64]bool)
+	for _, mig := range downMigrations {
+		redoMap[mig] = true
+	}
+
+	toRedo := make(filesystem.MigrationFileList, 0, len(downMigrations))
+	for _, mig := range files {
+		if redoMap[mig.Timestamp] {
+			toRedo = append(toRedo, mig)
+		}
+	}
+
+	return run.guarded(func() error {
+		err := run.executeDownMigrations(files, downMigrations)
+		if err != nil {
+			return err
+		}
+
+		return run.executeUpMigrations(toRedo, stayInDB)
+	})
+}
@@ -0,0 +1,141 @@
+package subcommands
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRedoRun(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+	t3, _ := time.Parse(time.RFC3339, "2020-10-22T10:00:00Z")
+
+	down := func(ts time.Time, sql string) models.ExecutionContext {
+		return models.ExecutionContext{Timestamp: ts.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", ts.Unix()), IsUp: false, Sql: sql}
+	}
+
+	up := func(ts time.Time, sql string) models.ExecutionContext {
+		return models.ExecutionContext{Timestamp: ts.Unix(), Name: fmt.Sprintf("mig_%d_up.sql", ts.Unix()), IsUp: true, Sql: sql}
+	}
+
+	table := []struct {
+		name        string
+		inDB        []int64
+		flags       []string
+		expected    []models.ExecutionContext
+		returnError bool
+	}{
+		{
+			name:  "redo last migration",
+			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
+			flags: []string{"-yes"},
+			expected: []models.ExecutionContext{
+				down(t3, "mig_3_down_sql"),
+				up(t3, "mig_3_up_sql"),
+			},
+		},
+		{
+			name:  "redo last two migrations",
+			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
+			flags: []string{"-n=2", "-allow-destructive"},
+			expected: []models.ExecutionContext{
+				down(t3, "mig_3_down_sql"),
+				down(t2, "mig_2_down_sql"),
+				up(t2, "mig_2_up_sql"),
+				up(t3, "mig_3_up_sql"),
+			},
+		},
+		{
+			name:     "dry run does not execute",
+			inDB:     []int64{t1.Unix(), t2.Unix(), t3.Unix()},
+			flags:    []string{"-n=3", "-dry-run"},
+			expected: []models.ExecutionContext{},
+		},
+		{
+			name:        "requires confirmation",
+			inDB:        []int64{t1.Unix(), t2.Unix(), t3.Unix()},
+			flags:       []string{},
+			expected:    []models.ExecutionContext{},
+			returnError: true,
+		},
+		{
+			name:        "too many steps",
+			inDB:        []int64{t1.Unix()},
+			flags:       []string{"-n=2"},
+			expected:    []models.ExecutionContext{},
+			returnError: true,
+		},
+		{
+			name:        "empty database",
+			inDB:        []int64{},
+			flags:       []string{},
+			expected:    []models.ExecutionContext{},
+			returnError: true,
+		},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("mig_1_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte("mig_2_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("mig_2_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t3.Unix()), []byte("mig_3_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t3.Unix()), []byte("mig_3_down_sql"), os.ModePerm)
+
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			mockedModels := mockedModels{}
+			mockedModels.On("GetMigrationsList").Return(v.inDB, nil)
+			for _, e := range v.expected {
+				mockedModels.On("Execute", e).Return(nil).Once()
+			}
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintDownMigration", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+
+			redo := Redo{
+				CommandBase: CommandBase{
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &mockedModels,
+					Flags:      v.flags,
+					Printer:    &mp,
+				},
+			}
+
+			err := redo.Run()
+			if v.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			mockedModels.AssertExpectations(t)
+
+			executed := make([]models.ExecutionContext, 0, len(v.expected))
+			for _, call := range mockedModels.Calls {
+				if call.Method == "Execute" {
+					executed = append(executed, call.Arguments.Get(0).(models.ExecutionContext))
+				}
+			}
+			r.Equal(v.expected, executed, "migrations executed in wrong order")
+		})
+	}
+}
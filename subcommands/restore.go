@@ -0,0 +1,196 @@
+package subcommands
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/djordjev/pg-mig/filesystem"
+)
+
+// Restore structure for restore command
+type Restore struct {
+	CommandBase
+}
+
+// Run restores database from a backup created before down migrations or squash
+func (restore *Restore) Run() error {
+	flagSet := flag.NewFlagSet("restore", flag.ExitOnError)
+
+	file := flagSet.String("file", "", "Backup file to restore")
+	latest := flagSet.Bool("latest", false, "Restore the newest backup from backup directory. Used instead of -file")
+	list := flagSet.Bool("list", false, "Print available backups without restoring anything")
+	target := flagSet.String("target", "", "Name of the target from config to restore. Its backups are used with -latest")
+	schema := flagSet.String("schema", "", "Tenant schema whose backup is used with -latest")
+	yes := flagSet.Bool("yes", false, "Restore without confirmation")
+	help := flagSet.Bool("help", false, "Prints help for restore command")
+
+	err := flagSet.Parse(restore.Flags)
+	if err != nil {
+		return fmt.Errorf("restore command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if *list {
+		return restore.printBackups()
+	}
+
+	// Targets are resolved against config before target is applied to it
+	targets := restore.Config.Targets
+	config := restore.Config
+
+	if *target != "" {
+		err = restore.useTarget(*target)
+		if err != nil {
+			return err
+		}
+	}
+
+	if *latest && isAmbiguousBackup(config, targets, *target, *schema) {
+		return fmt.Errorf("restore command error: backups of %s can't be told apart from backups of other targets, select backup with -file", backupName(restore.Config.DbName, *target, *schema))
+	}
+
+	location, err := restore.getBackup(*file, *latest, *target, *schema)
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		err = restore.confirm(location)
+		if err != nil {
+			return err
+		}
+	}
+
+	connectionString, err := restore.Config.GetConnectionString()
+	if err != nil {
+		return err
+	}
+
+	err = restore.Dumper.Restore(connectionString, location)
+	if err != nil {
+		return fmt.Errorf("restore command error: unable to restore %s %w", location, err)
+	}
+
+	restore.Printer.PrintSuccess(fmt.Sprintf("Database restored from %s", location))
+
+	return nil
+}
+
+func (restore *Restore) printBackups() error {
+	backups, err := restore.Filesystem.GetBackups(restore.Config)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		restore.Printer.PrintError(fmt.Sprintf("No backups found in %s", restore.Config.GetBackupDir()))
+		return nil
+	}
+
+	for _, backup := range backups {
+		restore.Printer.PrintSuccess(backup)
+	}
+
+	return nil
+}
+
+// useTarget restores into target with given name instead of database from config
+func (restore *Restore) useTarget(name string) error {
+	for _, target := range restore.Config.Targets {
+		if target.Name == name {
+			restore.Config = target.Apply(restore.Config)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("restore command error: unknown target %s", name)
+}
+
+// getBackup returns selected file or the newest backup of the database, target and schema
+// being restored. Backups of other databases, targets and tenants in the same directory are ignored.
+func (restore *Restore) getBackup(file string, latest bool, target string, schema string) (string, error) {
+	if file != "" && latest {
+		return "", fmt.Errorf("restore command error: -file and -latest can't be used together")
+	}
+
+	if file != "" {
+		return file, nil
+	}
+
+	if !latest {
+		return "", fmt.Errorf("restore command error: backup has to be selected with -file or -latest flag")
+	}
+
+	backups, err := restore.Filesystem.GetBackups(restore.Config)
+	if err != nil {
+		return "", err
+	}
+
+	name := backupName(restore.Config.DbName, target, schema)
+
+	matching := make([]string, 0, len(backups))
+	for _, backup := range backups {
+		if filesystem.IsBackupOf(backup, name) {
+			matching = append(matching, backup)
+		}
+	}
+
+	if len(matching) == 0 {
+		return "", fmt.Errorf("restore command error: no backups of %s found in %s, select backup with -file", name, restore.Config.GetBackupDir())
+	}
+
+	return matching[len(matching)-1], nil
+}
+
+// isAmbiguousBackup returns whether backups of the restored database have the same name as
+// backups of some other target from config, in which case the newest one can't be selected safely
+func isAmbiguousBackup(config filesystem.Config, targets []filesystem.Target, target string, schema string) bool {
+	dbName := config.DbName
+	for _, t := range targets {
+		if t.Name == target {
+			dbName = t.Apply(config).DbName
+		}
+	}
+
+	name := backupName(dbName, target, schema)
+
+	if target != "" && sharesBackupName(name, config.DbName) {
+		return true
+	}
+
+	for _, t := range targets {
+		if t.Name != target && sharesBackupName(name, backupName(t.Apply(config).DbName, t.Name, "")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sharesBackupName returns whether backups named name could also belong to database or any of its tenant schemas
+// whose backups are prefixed with other
+func sharesBackupName(name string, other string) bool {
+	return name == other || strings.HasPrefix(name, other+"_")
+}
+
+func (restore *Restore) confirm(location string) error {
+	if restore.Input == nil {
+		return fmt.Errorf("restore command error: restore requires confirmation, use -yes flag")
+	}
+
+	restore.Printer.PrintError(fmt.Sprintf("Objects in database %s will be replaced with the ones from %s", restore.Config.DbName, location))
+	restore.Printer.PrintError("Type yes to restore:")
+
+	answer, _ := bufio.NewReader(restore.Input).ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "yes" {
+		return fmt.Errorf("restore command error: restore was not confirmed")
+	}
+
+	return nil
+}
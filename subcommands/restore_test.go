@@ -0,0 +1,244 @@
+package subcommands
+
+import (
+	"errors"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRestoreRun(t *testing.T) {
+	backups := []string{"backups/shop_20201020T100000Z.dump", "backups/shop_20201021T100000Z.dump"}
+
+	// Backups of other databases, tenants and targets in the same directory
+	mixed := []string{
+		"backups/shop_20201020T100000Z.dump",
+		"backups/shop_tenant_a_20201021T100000Z.dump",
+		"backups/shop_20201022T100000Z.dump",
+		"backups/shard_1_shop_20201023T100000Z.dump",
+		"backups/shopping_20201024T100000Z.dump",
+		"backups/shop_tenant_a_20201025T100000Z.dump",
+	}
+
+	table := []struct {
+		name         string
+		flags        []string
+		backups      []string
+		input        io.Reader
+		restoreError error
+		restored     string
+		returnError  bool
+	}{
+		{
+			name:     "restores file",
+			flags:    []string{"-file=backups/custom.dump", "-yes"},
+			restored: "backups/custom.dump",
+		},
+		{
+			name:     "restores latest backup",
+			flags:    []string{"-latest", "-yes"},
+			backups:  backups,
+			restored: "backups/shop_20201021T100000Z.dump",
+		},
+		{
+			name:     "restore confirmed interactively",
+			flags:    []string{"-latest"},
+			backups:  backups,
+			input:    strings.NewReader("yes\n"),
+			restored: "backups/shop_20201021T100000Z.dump",
+		},
+		{
+			name:        "restore rejected interactively",
+			flags:       []string{"-latest"},
+			backups:     backups,
+			input:       strings.NewReader("no\n"),
+			returnError: true,
+		},
+		{
+			name:        "restore requires confirmation",
+			flags:       []string{"-file=backups/custom.dump"},
+			returnError: true,
+		},
+		{
+			name:     "restores latest backup of database among mixed backups",
+			flags:    []string{"-latest", "-yes"},
+			backups:  mixed,
+			restored: "backups/shop_20201022T100000Z.dump",
+		},
+		{
+			name:     "restores latest backup of tenant schema",
+			flags:    []string{"-latest", "-schema=tenant_a", "-yes"},
+			backups:  mixed,
+			restored: "backups/shop_tenant_a_20201025T100000Z.dump",
+		},
+		{
+			name:        "no backups of database",
+			flags:       []string{"-latest", "-yes"},
+			backups:     []string{"backups/shopping_20201024T100000Z.dump", "backups/shop_tenant_a_20201025T100000Z.dump"},
+			returnError: true,
+		},
+		{
+			name:        "unknown target",
+			flags:       []string{"-latest", "-target=shard_7", "-yes"},
+			backups:     mixed,
+			returnError: true,
+		},
+		{
+			name:        "no backups",
+			flags:       []string{"-latest", "-yes"},
+			backups:     []string{},
+			returnError: true,
+		},
+		{
+			name:        "backup has to be selected",
+			flags:       []string{"-yes"},
+			returnError: true,
+		},
+		{
+			name:        "file and latest together",
+			flags:       []string{"-file=backups/custom.dump", "-latest", "-yes"},
+			returnError: true,
+		},
+		{
+			name:         "pg_restore fails",
+			flags:        []string{"-file=backups/custom.dump", "-yes"},
+			restoreError: errors.New("pg_restore: error"),
+			restored:     "backups/custom.dump",
+			returnError:  true,
+		},
+		{
+			name:    "lists backups",
+			flags:   []string{"-list"},
+			backups: backups,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := mockedFilesystem{}
+			fs.On("GetBackups", backupConfig).Return(test.backups, nil)
+
+			d := mockedDumper{}
+			d.On("Restore", backupConnString, mock.Anything).Return(test.restoreError)
+
+			mp := mockedPrinter{}
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+
+			restore := Restore{
+				CommandBase: CommandBase{
+					Config:     backupConfig,
+					Filesystem: &fs,
+					Flags:      test.flags,
+					Printer:    &mp,
+					Dumper:     &d,
+					Input:      test.input,
+				},
+			}
+
+			err := restore.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			if test.restored != "" {
+				d.AssertCalled(t, "Restore", backupConnString, test.restored)
+			} else {
+				d.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+			}
+
+			if test.name == "lists backups" {
+				for _, backup := range backups {
+					mp.AssertCalled(t, "PrintSuccess", backup)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreRunEmptyList(t *testing.T) {
+	r := require.New(t)
+
+	fs := mockedFilesystem{}
+	fs.On("GetBackups", filesystem.Config{}).Return([]string{}, nil)
+
+	mp := mockedPrinter{}
+	mp.On("PrintError", "No backups found in backups").Once()
+
+	restore := Restore{CommandBase: CommandBase{Filesystem: &fs, Flags: []string{"-list"}, Printer: &mp}}
+
+	r.NoError(restore.Run())
+	mp.AssertExpectations(t)
+}
+
+func TestRestoreLatestOfTarget(t *testing.T) {
+	r := require.New(t)
+
+	config := backupConfig
+	config.Targets = []filesystem.Target{{Name: "shard_1", DbURL: "shard1"}, {Name: "shard_2", DbURL: "shard2"}}
+
+	fs := mockedFilesystem{}
+	fs.On("GetBackups", mock.Anything).Return([]string{
+		"backups/shard_1_shop_20201020T100000Z.dump",
+		"backups/shard_2_shop_20201021T100000Z.dump",
+		"backups/shop_20201022T100000Z.dump",
+	}, nil)
+
+	d := mockedDumper{}
+	d.On("Restore", "postgres://u:p@shard1:5432/shop?sslmode=disable", "backups/shard_1_shop_20201020T100000Z.dump").Return(nil).Once()
+
+	mp := mockedPrinter{}
+	mp.On("PrintSuccess", mock.Anything)
+
+	restore := Restore{CommandBase: CommandBase{Config: config, Filesystem: &fs, Flags: []string{"-latest", "-target=shard_1", "-yes"}, Printer: &mp, Dumper: &d}}
+
+	r.NoError(restore.Run())
+	d.AssertExpectations(t)
+}
+
+func TestIsAmbiguousBackup(t *testing.T) {
+	config := filesystem.Config{DbName: "shop"}
+
+	table := []struct {
+		name      string
+		targets   []filesystem.Target
+		target    string
+		schema    string
+		ambiguous bool
+	}{
+		{name: "database without targets"},
+		{name: "target with the same database", targets: []filesystem.Target{{Name: "shard_1"}, {Name: "shard_2"}}, target: "shard_1"},
+		{name: "tenant schema", schema: "tenant_a"},
+		{
+			name:      "other target has the same backup name",
+			targets:   []filesystem.Target{{Name: "a", DbName: "b_shop"}, {Name: "a_b", DbName: "shop"}},
+			target:    "a",
+			ambiguous: true,
+		},
+		{
+			name:      "target has the same backup name as tenant schema of database",
+			targets:   []filesystem.Target{{Name: "shop", DbName: "tenant_a"}},
+			target:    "shop",
+			ambiguous: true,
+		},
+		{
+			name:      "tenant schema has the same backup name as other target",
+			targets:   []filesystem.Target{{Name: "shop", DbName: "tenant_a"}},
+			schema:    "tenant_a",
+			ambiguous: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.ambiguous, isAmbiguousBackup(config, test.targets, test.target, test.schema))
+		})
+	}
+}
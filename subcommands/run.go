@@ -1,11 +1,14 @@
 package subcommands
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"github.com/djordjev/pg-mig/filesystem"
 	"github.com/djordjev/pg-mig/models"
 	"sort"
+	"strings"
 	"time"
 )
 
@@ -13,17 +16,65 @@ import (
 type Run struct {
 	CommandBase
 	isDryRun bool
+	printSQL bool
+	// collect receives every step instead of executing it when set (used by plan command)
+	collect func(execContext models.ExecutionContext) error
+	// executed number of migrations executed (or printed in dry-run mode)
+	executed int
+	// verbose prints progress and duration of each executed statement
+	verbose bool
+	// allowDestructive skips confirmation of down migrations and destructive statements
+	allowDestructive bool
+	// answers reads confirmations from Input. It's shared by tenant runs so that answers
+	// piped for several prompts aren't lost in buffers of separate readers.
+	answers *bufio.Reader
+	// backupFirst creates database backup before executing down migrations or destructive statements
+	backupFirst bool
+	// tenantSchema schema that is migrated in multi-tenant runs, empty otherwise
+	tenantSchema string
+	// targetName name of the target that is migrated in runs against multiple targets, empty otherwise
+	targetName string
+	// slowThreshold duration after which executed migration is reported as slow, zero disables it
+	slowThreshold time.Duration
+	// failOnSlow fails the run when some of the migrations were slow
+	failOnSlow bool
+	// timings durations of executed migrations in execution order
+	timings []migrationTiming
+	// hookFiles content of SQL hook files keyed by hook name, loaded on first use
+	hookFiles map[string]string
+	// spanContext context of the current run span, nil when tracing is disabled
+	spanContext context.Context
 }
 
 // Run executes up/down migrations
 func (run *Run) Run() error {
 	flagSet := flag.NewFlagSet("run", flag.ExitOnError)
 
+	slowThreshold, err := run.Config.GetSlowThreshold()
+	if err != nil {
+		return err
+	}
+
 	strTime := flagSet.String("time", "", "Time on which you want to upgrade/downgrade DB. Omit for current time")
 	dryRun := flagSet.Bool("dry-run", false, "Run command in order to just print migrations that would be executed for given args without actually executing them.")
+	printSQL := flagSet.Bool("sql", false, "In dry-run mode also print full SQL of each step including meta table changes")
+	vars := templateVars{}
+	flagSet.Var(vars, "var", "Template variable in form key=value. Can be repeated")
+	schemas := flagSet.String("schemas", strings.Join(run.Config.Schemas, ","), "Comma separated list of tenant schemas to run migrations in, each with its own meta table")
+	schemasQuery := flagSet.String("schemas-query", run.Config.SchemasQuery, "SQL query returning names of tenant schemas to run migrations in")
+	onFailure := flagSet.String("on-failure", run.Config.GetOnTenantFailure(), "What to do when migrations fail in a tenant schema: stop or continue")
+	targetNames := flagSet.String("targets", "", "Comma separated names of targets from config or targets file to run migrations against, or all")
+	targetsFile := flagSet.String("targets-file", "", "JSON file with list of targets. All of them are used unless -targets is provided")
+	concurrency := flagSet.Int("concurrency", 4, "Maximum number of targets migrated at the same time")
+	verbose := flagSet.Bool("v", false, "Verbose output. Prints progress and duration of each executed statement")
+	yes := flagSet.Bool("yes", false, "Execute down migrations and destructive statements without confirmation")
+	allowDestructive := flagSet.Bool("allow-destructive", false, "Same as -yes")
+	backup := flagSet.Bool("backup", run.Config.Backup, "Back up database with pg_dump before executing down migrations or destructive statements")
+	slow := flagSet.Duration("slow", slowThreshold, "Warn about migrations running longer than given duration, for example 30s")
+	failOnSlow := flagSet.Bool("fail-on-slow", run.Config.FailOnSlow, "Fail the run when some of the migrations exceed -slow threshold")
 	help := flagSet.Bool("help", false, "Prints help for run command")
 
-	err := flagSet.Parse(run.Flags)
+	err = flagSet.Parse(run.Flags)
 	if err != nil {
 		return fmt.Errorf("run command error: unable to parse program flags %w", err)
 	}
@@ -34,7 +85,70 @@ func (run *Run) Run() error {
 	}
 
 	run.isDryRun = *dryRun
+	run.printSQL = *printSQL
+	run.allowDestructive = *yes || *allowDestructive || run.AllowDestructive
+	run.answers = newAnswers(run.Input)
+	run.backupFirst = *backup
+	run.slowThreshold = *slow
+	run.failOnSlow = *failOnSlow
+	run.verbose = *verbose
+	run.Config.CliVars = vars
+
+	targets, err := run.getTargets(*targetNames, *targetsFile)
+	if err != nil {
+		return err
+	}
+
+	if len(targets) > 0 {
+		// Schemas query is not executed as there is no connection on database from config
+		listed, err := run.getTenantSchemas(*schemas, "")
+		if err != nil {
+			return err
+		}
+
+		if len(listed) > 0 || *schemasQuery != "" {
+			return fmt.Errorf("run command error: targets and tenant schemas can't be used together")
+		}
+
+		return run.migrateTargets(strTime, targets, *concurrency)
+	}
+
+	tenants, err := run.getTenantSchemas(*schemas, *schemasQuery)
+	if err != nil {
+		return err
+	}
+
+	if len(tenants) > 0 {
+		return run.migrateTenants(strTime, tenants, *onFailure)
+	}
+
+	return run.migrate(strTime)
+}
 
+// migrate brings database to the state at given time and applies changed repeatable migrations.
+// Destructive changes have to be confirmed first. Durations of executed migrations are reported at the end.
+func (run *Run) migrate(strTime *string) (err error) {
+	endSpan := run.startRunSpan()
+	defer func() { endSpan(err) }()
+
+	err = run.guarded(func() error {
+		return run.migrateSteps(strTime)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !run.isDryRun && run.collect == nil {
+		err = run.reportSchemaVersion()
+		if err != nil {
+			return err
+		}
+	}
+
+	return run.reportTimings()
+}
+
+func (run *Run) migrateSteps(strTime *string) error {
 	// TODO check file formats and matching down files
 	inDB, err := run.Models.GetMigrationsList()
 	if err != nil {
@@ -51,6 +165,11 @@ func (run *Run) Run() error {
 		return err
 	}
 
+	err = run.runHook(filesystem.HookBeforeAll, HookContext{})
+	if err != nil {
+		return err
+	}
+
 	err = run.executeUpMigrations(stay, inDB)
 	if err != nil {
 		return err
@@ -63,6 +182,16 @@ func (run *Run) Run() error {
 		return err
 	}
 
+	err = run.executeRepeatableMigrations()
+	if err != nil {
+		return err
+	}
+
+	err = run.runHook(filesystem.HookAfterAll, HookContext{})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -77,9 +206,90 @@ func (run *Run) getMigrationFiles(border time.Time) (stay, goDown filesystem.Mig
 		return nil, nil, err
 	}
 
+	stay, err = run.mergeGoMigrations(stay, time.Time{}, border)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	goDown, err = run.mergeGoMigrations(goDown, border, run.Timer.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return
 }
 
+// mergeGoMigrations adds registered Go migrations between from (exclusive) and to (inclusive)
+// into list of migration files keeping it ordered by timestamp
+func (run *Run) mergeGoMigrations(files filesystem.MigrationFileList, from time.Time, to time.Time) (filesystem.MigrationFileList, error) {
+	if len(run.GoMigrations) == 0 {
+		return files, nil
+	}
+
+	onFS := make(map[int64]bool)
+	for _, file := range files {
+		onFS[file.Timestamp] = true
+	}
+
+	result := append(filesystem.MigrationFileList{}, files...)
+
+	for ts, mig := range run.GoMigrations {
+		if ts <= from.Unix() || ts > to.Unix() {
+			continue
+		}
+
+		if onFS[ts] {
+			return nil, fmt.Errorf("run command error: go migration %s has the same timestamp %d as migration file", mig.Name, ts)
+		}
+
+		result = append(result, filesystem.MigrationFile{
+			Timestamp: ts,
+			Up:        fmt.Sprintf("go:%d_%s_up", ts, mig.Name),
+			Down:      fmt.Sprintf("go:%d_%s_down", ts, mig.Name),
+		})
+	}
+
+	sort.Sort(result)
+
+	return result, nil
+}
+
+// buildExecutionContext prepares execution of migration in given direction either
+// from migration file content or from registered Go migration
+func (run *Run) buildExecutionContext(mig filesystem.MigrationFile, direction filesystem.Direction) (models.ExecutionContext, error) {
+	execContext := models.ExecutionContext{
+		IsUp:      direction == filesystem.DirectionUp,
+		Timestamp: mig.Timestamp,
+		Name:      mig.Down,
+	}
+
+	if execContext.IsUp {
+		execContext.Name = mig.Up
+	}
+
+	if goMig, ok := run.GoMigrations[mig.Timestamp]; ok {
+		execContext.Func = goMig.Down
+		if execContext.IsUp {
+			execContext.Func = goMig.Up
+		}
+
+		if execContext.Func == nil {
+			return execContext, fmt.Errorf("run command error: go migration %s has no function for %s", goMig.Name, direction)
+		}
+
+		return execContext, nil
+	}
+
+	content, err := run.Filesystem.ReadMigrationContent(mig, direction, run.Config)
+	if err != nil {
+		return execContext, err
+	}
+
+	execContext.Sql = content
+
+	return execContext, nil
+}
+
 func (run *Run) getInDBDownMigrations(inDB []int64, border time.Time) []int64 {
 	result := make([]int64, 0, 10)
 
@@ -147,30 +357,22 @@ func (run *Run) executeUpMigrations(stay filesystem.MigrationFileList, inDB []in
 			continue
 		}
 
-		content, err := run.Filesystem.ReadMigrationContent(mig, filesystem.DirectionUp, run.Config)
+		execContext, err := run.buildExecutionContext(mig, filesystem.DirectionUp)
 		if err != nil {
 			return err
 		}
 
-		execContext := models.ExecutionContext{
-			Sql:       content,
-			IsUp:      true,
-			Timestamp: mig.Timestamp,
-			Name:      mig.Up,
-		}
-
-		isEmpty := execContext.Sql == ""
+		isEmpty := execContext.Sql == "" && execContext.Func == nil
 		emptyText := ""
 		if isEmpty {
 			emptyText = "EMPTY"
 		}
 
 		run.Printer.PrintUpMigration(fmt.Sprintf("Executing up %s migration %s", emptyText, execContext.Name))
-		if !run.isDryRun {
-			err = run.Models.Execute(execContext)
-			if err != nil {
-				return err
-			}
+
+		err = run.executeWithHooks(execContext)
+		if err != nil {
+			return err
 		}
 
 	}
@@ -183,7 +385,6 @@ func (run *Run) executeDownMigrations(down filesystem.MigrationFileList, downIDs
 	}
 
 	toExecuteMap := make(map[int64]filesystem.MigrationFile)
-	config := run.Config
 
 	for _, mig := range down {
 		toExecuteMap[mig.Timestamp] = mig
@@ -195,19 +396,12 @@ func (run *Run) executeDownMigrations(down filesystem.MigrationFileList, downIDs
 			return fmt.Errorf("run command error: in db there's a executed migration with timestamp %d but migrations down file is missing on filesystem", toExec)
 		}
 
-		content, err := run.Filesystem.ReadMigrationContent(current, filesystem.DirectionDown, config)
+		execContext, err := run.buildExecutionContext(current, filesystem.DirectionDown)
 		if err != nil {
 			return err
 		}
 
-		execContext := models.ExecutionContext{
-			Sql:       content,
-			IsUp:      false,
-			Timestamp: current.Timestamp,
-			Name:      current.Down,
-		}
-
-		isEmpty := execContext.Sql == ""
+		isEmpty := execContext.Sql == "" && execContext.Func == nil
 		emptyText := ""
 		if isEmpty {
 			emptyText = "EMPTY"
@@ -215,14 +409,169 @@ func (run *Run) executeDownMigrations(down filesystem.MigrationFileList, downIDs
 
 		run.Printer.PrintDownMigration(fmt.Sprintf("Executing %s down migration %s", emptyText, execContext.Name))
 
-		if !run.isDryRun {
-			err = run.Models.Execute(execContext)
+		err = run.executeWithHooks(execContext)
+		if err != nil {
+			return err
+		}
+
+	}
+
+	return nil
+}
+
+func (run *Run) executeRepeatableMigrations() error {
+	repeatable, err := run.Filesystem.GetRepeatableMigrations()
+	if err != nil {
+		return err
+	}
+
+	if len(repeatable) == 0 {
+		return nil
+	}
+
+	applied, err := run.Models.GetRepeatableChecksums()
+	if err != nil {
+		return err
+	}
+
+	for _, mig := range repeatable {
+		mig.Content, err = filesystem.RenderTemplate(mig.Name, mig.Content, run.Config)
+		if err != nil {
+			return err
+		}
+
+		checksum := mig.Checksum()
+		if applied[mig.Name] == checksum {
+			continue
+		}
+
+		execContext := models.ExecutionContext{
+			Sql:        mig.Content,
+			IsUp:       true,
+			Name:       mig.Name,
+			Repeatable: true,
+			Checksum:   checksum,
+		}
+
+		run.Printer.PrintUpMigration(fmt.Sprintf("Executing repeatable migration %s", execContext.Name))
+
+		// before_each and after_each hooks surround only versioned migrations
+		err = run.execute(execContext)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// executeWithHooks runs migration surrounded with before_each and after_each hooks
+func (run *Run) executeWithHooks(execContext models.ExecutionContext) error {
+	hookContext := HookContext{Name: execContext.Name, Timestamp: execContext.Timestamp, IsUp: execContext.IsUp}
+
+	err := run.runHook(filesystem.HookBeforeEach, hookContext)
+	if err != nil {
+		return err
+	}
+
+	err = run.execute(execContext)
+	if err != nil {
+		return err
+	}
+
+	return run.runHook(filesystem.HookAfterEach, hookContext)
+}
+
+// execute runs migration unless in dry-run mode where its full SQL is optionally printed
+func (run *Run) execute(execContext models.ExecutionContext) error {
+	run.executed++
+
+	if run.collect != nil {
+		return run.collect(execContext)
+	}
+
+	if !run.isDryRun {
+		if run.verbose {
+			execContext.Progress = run.printProgress
+		}
+
+		return run.instrumented(execContext, func(ctx context.Context) (time.Duration, error) {
+			if run.Tracer != nil {
+				// Go migrations continue the trace within migration span
+				execContext.Context = ctx
+			}
+
+			start := run.Timer.Now()
+
+			err := run.Models.Execute(execContext)
 			if err != nil {
-				return err
+				return 0, err
 			}
-		}
 
+			duration := run.Timer.Now().Sub(start)
+			run.recordTiming(execContext.Name, duration)
+
+			return duration, nil
+		})
+	}
+
+	if !run.printSQL {
+		return nil
+	}
+
+	if execContext.Func != nil {
+		run.Printer.PrintSQL(fmt.Sprintf("-- %s is a go migration and has no SQL representation", execContext.Name))
+		return nil
+	}
+
+	script, err := models.Script(execContext, run.Meta)
+	if err != nil {
+		return err
+	}
+
+	run.Printer.PrintSQL(script)
+
+	return nil
+}
+
+// printProgress prints executed statement with its position in migration and duration
+func (run *Run) printProgress(progress models.StatementProgress) {
+	statement := progress.Statement
+	if end := strings.IndexByte(statement, '\n'); end >= 0 {
+		statement = statement[:end] + " ..."
 	}
 
+	run.Printer.PrintSQL(fmt.Sprintf(
+		"  [%d/%d] line %d, %s: %s",
+		progress.Index,
+		progress.Total,
+		progress.Line,
+		progress.Duration.Round(time.Millisecond),
+		statement,
+	))
+}
+
+// templateVars collects repeated -var key=value flags
+type templateVars map[string]string
+
+func (v templateVars) String() string {
+	pairs := make([]string, 0, len(v))
+	for key, value := range v {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+func (v templateVars) Set(value string) error {
+	pair := strings.SplitN(value, "=", 2)
+	if len(pair) != 2 || pair[0] == "" {
+		return fmt.Errorf("invalid variable %s, expected key=value", value)
+	}
+
+	v[pair[0]] = pair[1]
+
 	return nil
 }
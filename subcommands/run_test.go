@@ -1,11 +1,13 @@
 package subcommands
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/djordjev/pg-mig/filesystem"
 	"github.com/djordjev/pg-mig/models"
 	"github.com/djordjev/pg-mig/timer"
+	"github.com/jackc/pgx/v4"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -203,12 +205,14 @@ func TestExecuteUpMigrations(t *testing.T) {
 			mp := mockedPrinter{}
 
 			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
 
 			run := Run{
 				CommandBase: CommandBase{
 					Filesystem: fs,
 					Models:     m,
 					Printer:    &mp,
+					Timer:      timer.Timer{Now: time.Now},
 				},
 			}
 
@@ -283,12 +287,14 @@ func TestExecuteDownMigrations(t *testing.T) {
 			mp := mockedPrinter{}
 
 			mp.On("PrintDownMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
 
 			run := Run{
 				CommandBase: CommandBase{
 					Filesystem: fs,
 					Models:     m,
 					Printer:    &mp,
+					Timer:      timer.Timer{Now: time.Now},
 				},
 			}
 
@@ -419,7 +425,7 @@ func TestRun(t *testing.T) {
 		{
 			name:  "execute last down migration",
 			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
-			flags: []string{"-time=2020-10-21T10:00:00Z"},
+			flags: []string{"-time=2020-10-21T10:00:00Z", "-yes"},
 			expected: []models.ExecutionContext{
 				{Timestamp: t3.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t3.Unix()), IsUp: false, Sql: "mig_3_down_sql"},
 			},
@@ -428,7 +434,7 @@ func TestRun(t *testing.T) {
 		{
 			name:  "execute last two down migration",
 			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
-			flags: []string{"-time=2020-10-20T11:00:00Z"},
+			flags: []string{"-time=2020-10-20T11:00:00Z", "-yes"},
 			expected: []models.ExecutionContext{
 				{Timestamp: t2.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t2.Unix()), IsUp: false, Sql: "mig_2_down_sql"},
 				{Timestamp: t3.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t3.Unix()), IsUp: false, Sql: "mig_3_down_sql"},
@@ -438,7 +444,7 @@ func TestRun(t *testing.T) {
 		{
 			name:  "execute all down migration",
 			inDB:  []int64{t1.Unix(), t2.Unix(), t3.Unix()},
-			flags: []string{"-time=2010-10-20T11:00:00Z"},
+			flags: []string{"-time=2010-10-20T11:00:00Z", "-yes"},
 			expected: []models.ExecutionContext{
 				{Timestamp: t1.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t1.Unix()), IsUp: false, Sql: "mig_1_down_sql"},
 				{Timestamp: t2.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t2.Unix()), IsUp: false, Sql: "mig_2_down_sql"},
@@ -458,7 +464,7 @@ func TestRun(t *testing.T) {
 		{
 			name:  "execute pop",
 			inDB:  []int64{t1.Unix(), t2.Unix()},
-			flags: []string{"-time=pop"},
+			flags: []string{"-time=pop", "-yes"},
 			expected: []models.ExecutionContext{
 				{Timestamp: t2.Unix(), Name: fmt.Sprintf("mig_%d_down.sql", t2.Unix()), IsUp: false, Sql: "mig_2_down_sql"},
 			},
@@ -496,6 +502,7 @@ func TestRun(t *testing.T) {
 			}
 
 			mp := mockedPrinter{}
+			mp.On("PrintSuccess", mock.Anything)
 			if v.printer != "" {
 				mp.On(v.printer, mock.Anything)
 			}
@@ -516,3 +523,229 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteRepeatableMigrations(t *testing.T) {
+	views := filesystem.RepeatableFile{Name: "rep_views.sql", Content: "create or replace view v as select 1;"}
+	functions := filesystem.RepeatableFile{Name: "rep_functions.sql", Content: "create or replace function f() returns int as 'select 1' language sql;"}
+
+	table := []struct {
+		name          string
+		repeatable    filesystem.RepeatableFileList
+		applied       map[string]string
+		expectedToRun []filesystem.RepeatableFile
+	}{
+		{
+			name:          "no repeatable migrations",
+			repeatable:    filesystem.RepeatableFileList{},
+			expectedToRun: []filesystem.RepeatableFile{},
+		},
+		{
+			name:          "runs new migrations",
+			repeatable:    filesystem.RepeatableFileList{functions, views},
+			applied:       map[string]string{},
+			expectedToRun: []filesystem.RepeatableFile{functions, views},
+		},
+		{
+			name:          "runs only changed migrations",
+			repeatable:    filesystem.RepeatableFileList{functions, views},
+			applied:       map[string]string{functions.Name: functions.Checksum(), views.Name: "outdated"},
+			expectedToRun: []filesystem.RepeatableFile{views},
+		},
+	}
+
+	for _, v := range table {
+		t.Run(v.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := &mockedFilesystem{}
+			m := &mockedModels{}
+			mp := mockedPrinter{}
+
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			fs.On("GetRepeatableMigrations").Return(v.repeatable, nil)
+			if v.applied != nil {
+				m.On("GetRepeatableChecksums").Return(v.applied, nil)
+			}
+
+			for _, migration := range v.expectedToRun {
+				m.On("Execute", models.ExecutionContext{
+					Sql:        migration.Content,
+					IsUp:       true,
+					Name:       migration.Name,
+					Repeatable: true,
+					Checksum:   migration.Checksum(),
+				}).Return(nil).Once()
+			}
+
+			run := Run{
+				CommandBase: CommandBase{
+					Filesystem: fs,
+					Models:     m,
+					Printer:    &mp,
+					Timer:      timer.Timer{Now: time.Now},
+				},
+			}
+
+			r.NoError(run.executeRepeatableMigrations())
+
+			fs.AssertExpectations(t)
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGoMigrations(t *testing.T) {
+	r := require.New(t)
+
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+	t3, _ := time.Parse(time.RFC3339, "2020-10-22T10:00:00Z")
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("mig_1_up_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t3.Unix()), []byte("mig_3_up_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t3.Unix()), []byte("mig_3_down_sql"), os.ModePerm)
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+	getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+	var executed []string
+	goMigration := models.GoMigration{
+		Timestamp: t2.Unix(),
+		Name:      "backfill",
+		Up: func(ctx context.Context, tx pgx.Tx) error {
+			executed = append(executed, "up")
+			return nil
+		},
+	}
+
+	m := &mockedModels{}
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", mock.Anything)
+	mp.On("PrintDownMigration", mock.Anything)
+	mp.On("PrintSuccess", mock.Anything)
+
+	run := Run{
+		CommandBase: CommandBase{
+			Filesystem:   &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+			Timer:        timer.Timer{Now: getNow},
+			Models:       m,
+			Printer:      &mp,
+			GoMigrations: map[int64]models.GoMigration{t2.Unix(): goMigration},
+		},
+	}
+
+	stay, down, err := run.getMigrationFiles(getNow())
+	r.NoError(err)
+	r.Empty(down)
+	r.Len(stay, 3)
+	r.Equal(t2.Unix(), stay[1].Timestamp, "go migration should be ordered between files")
+	r.Equal(fmt.Sprintf("go:%d_backfill_up", t2.Unix()), stay[1].Up)
+
+	m.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+		execContext := args.Get(0).(models.ExecutionContext)
+		if execContext.Func != nil {
+			_ = execContext.Func(context.Background(), nil)
+		}
+	}).Return(nil)
+
+	r.NoError(run.executeUpMigrations(stay, []int64{t1.Unix()}))
+	r.Equal([]string{"up"}, executed)
+	m.AssertNumberOfCalls(t, "Execute", 2)
+
+	// Go migration without down function can't be rolled back
+	r.Error(run.executeDownMigrations(stay, []int64{t2.Unix()}))
+
+	// Go migration can't share timestamp with migration files
+	run.GoMigrations = map[int64]models.GoMigration{t3.Unix(): goMigration}
+	_, _, err = run.getMigrationFiles(getNow())
+	r.Error(err)
+}
+
+func TestRunTemplateDryRun(t *testing.T) {
+	r := require.New(t)
+
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create schema {{.schema}};"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("drop schema {{.schema}};"), os.ModePerm)
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+	getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+	m := mockedModels{}
+	m.On("GetMigrationsList").Return([]int64{}, nil)
+
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", mock.Anything)
+	mp.On("PrintSQL", fmt.Sprintf(
+		"-- mig_%d_up.sql\nBEGIN;\ninsert into __pg_mig_meta (ts) values (to_timestamp(%d));\ncreate schema tenant_a;\nCOMMIT;\n",
+		t1.Unix(),
+		t1.Unix(),
+	)).Once()
+
+	run := Run{
+		CommandBase: CommandBase{
+			Config:     filesystem.Config{Template: true},
+			Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+			Timer:      timer.Timer{Now: getNow},
+			Models:     &m,
+			Flags:      []string{"-dry-run", "-sql", "-var", "schema=tenant_a"},
+			Printer:    &mp,
+		},
+	}
+
+	r.NoError(run.Run())
+
+	mp.AssertExpectations(t)
+	m.AssertNotCalled(t, "Execute", mock.Anything)
+}
+
+func TestRunVerbose(t *testing.T) {
+	r := require.New(t)
+
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("create table a();"), os.ModePerm)
+	_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("drop table a;"), os.ModePerm)
+	_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+	getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+	m := mockedModels{}
+	m.On("GetMigrationsList").Return([]int64{}, nil)
+	m.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+		execContext := args.Get(0).(models.ExecutionContext)
+		r.NotNil(execContext.Progress)
+
+		execContext.Progress(models.StatementProgress{
+			Index:     1,
+			Total:     2,
+			Line:      3,
+			Statement: "create table a(\n  id int\n)",
+			Duration:  1500 * time.Microsecond,
+		})
+	}).Return(nil)
+
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", mock.Anything)
+	mp.On("PrintSuccess", mock.Anything)
+	mp.On("PrintSQL", "  [1/2] line 3, 2ms: create table a( ...").Once()
+
+	run := Run{
+		CommandBase: CommandBase{
+			Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+			Timer:      timer.Timer{Now: getNow},
+			Models:     &m,
+			Flags:      []string{"-v"},
+			Printer:    &mp,
+		},
+	}
+
+	r.NoError(run.Run())
+	mp.AssertExpectations(t)
+}
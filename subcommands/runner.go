@@ -5,7 +5,9 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/djordjev/pg-mig/dump"
 	"github.com/djordjev/pg-mig/filesystem"
 	"github.com/djordjev/pg-mig/models"
 	"github.com/djordjev/pg-mig/timer"
@@ -15,7 +17,15 @@ const cmdInit = "init"
 const cmdAdd = "add"
 const cmdRun = "run"
 const cmdSquash = "squash"
+const cmdUnsquash = "unsquash"
 const cmdLog = "log"
+const cmdRedo = "redo"
+const cmdBaseline = "baseline"
+const cmdSeed = "seed"
+const cmdPlan = "plan"
+const cmdLint = "lint"
+const cmdRestore = "restore"
+const cmdWait = "wait"
 const cmdHelp = "help"
 
 // Runner structure used for instantiating selected subcommand
@@ -29,7 +39,7 @@ type Runner struct {
 }
 
 // Run runs command selected from args
-func (runner *Runner) Run() error {
+func (runner *Runner) Run() (err error) {
 	if runner.Subcommand == cmdInit {
 		err := runner.createInitFile()
 		if err != nil {
@@ -44,29 +54,78 @@ func (runner *Runner) Run() error {
 
 	runner.Printer.SetNoColor(config.NoColor)
 
-	connectionString, err := config.GetConnectionString()
+	meta, err := models.NewMetaTable(config.MetaSchema, config.MetaTable)
 	if err != nil {
 		return err
 	}
 
-	conn, err := runner.Connector(context.Background(), connectionString)
-	if err != nil {
-		return fmt.Errorf("run error: unable to connect on database using connection string %s", connectionString)
+	if runner.Subcommand == cmdWait {
+		wait := Wait{CommandBase: CommandBase{
+			Config:    config,
+			Flags:     runner.Flags,
+			Timer:     runner.Timer,
+			Printer:   runner.Printer,
+			Connector: runner.Connector,
+		}}
+
+		return wait.Run()
+	}
+
+	// Linting reads only migration files so it doesn't need database connection
+	if runner.Subcommand == cmdLint {
+		lint := Lint{CommandBase: CommandBase{
+			Config:     config,
+			Flags:      runner.Flags,
+			Filesystem: runner.Fs,
+			Timer:      runner.Timer,
+			Printer:    runner.Printer,
+		}}
+
+		return lint.Run()
 	}
-	defer func() {
-		err := conn.Close(context.Background())
-		if err != nil {
-			panic("Unable to close connection to DB")
-		}
-	}()
 
 	base := CommandBase{
 		Config:     config,
-		Models:     &models.ImplModels{Db: conn},
 		Flags:      runner.Flags,
 		Filesystem: runner.Fs,
 		Timer:      runner.Timer,
 		Printer:    runner.Printer,
+		Dumper:     &dump.PgDump{Path: config.PgDumpPath, RestorePath: config.PgRestorePath, MetaTable: meta.String()},
+		Connector:  runner.Connector,
+		Meta:       meta,
+		Input:      os.Stdin,
+	}
+
+	// Each target is connected on separately so database from config isn't needed
+	if runner.Subcommand != cmdRun || !usesTargets(runner.Flags) {
+		connectionString, err := config.GetConnectionString()
+		if err != nil {
+			return err
+		}
+
+		policy, err := RetryPolicy(config)
+		if err != nil {
+			return err
+		}
+
+		conn, err := runner.Connector(context.Background(), connectionString, policy)
+		if err != nil {
+			return fmt.Errorf("run error: unable to connect on database %s %w", config.DbName, err)
+		}
+		defer func() {
+			closeErr := conn.Close(context.Background())
+			if closeErr != nil && err == nil {
+				err = fmt.Errorf("run error: unable to close connection to database %w", closeErr)
+			}
+		}()
+
+		base.Models = &models.ImplModels{Db: conn, Meta: meta}
+
+		// Meta table created by older versions is missing columns that every command reads
+		err = prepareMetaTable(base.Models, isReadOnly(runner.Subcommand, runner.Flags))
+		if err != nil {
+			return err
+		}
 	}
 
 	subcommand, err := runner.getSubcommand(&base)
@@ -85,6 +144,55 @@ func (runner *Runner) Run() error {
 	return err
 }
 
+// usesTargets returns whether flags of run command select targets to migrate
+func usesTargets(flags []string) bool {
+	return hasFlag(flags, "targets") || hasFlag(flags, "targets-file")
+}
+
+// isReadOnly returns whether command only reads the database so it must not alter meta table
+func isReadOnly(subcommand string, flags []string) bool {
+	return subcommand == cmdPlan || subcommand == cmdLog || hasFlag(flags, "dry-run")
+}
+
+// hasFlag returns whether flag with given name is set, boolean flags set to false are ignored
+func hasFlag(flags []string, name string) bool {
+	for _, arg := range flags {
+		if arg == "--" {
+			return false
+		}
+
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		parts := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)
+		if parts[0] == name {
+			return len(parts) == 1 || parts[1] != "false"
+		}
+	}
+
+	return false
+}
+
+// prepareMetaTable upgrades meta table created by an older version. Read only commands
+// can't alter database so they fail instead asking to upgrade meta table first.
+func prepareMetaTable(m models.Models, readOnly bool) error {
+	if !readOnly {
+		return m.UpgradeMetaTable()
+	}
+
+	outdated, err := m.MetaTableOutdated()
+	if err != nil {
+		return err
+	}
+
+	if outdated {
+		return fmt.Errorf("run error: meta table was created by an older version of pg-mig, run init (or any command without -dry-run) to upgrade it first")
+	}
+
+	return nil
+}
+
 func (runner *Runner) getSubcommand(base *CommandBase) (Command, error) {
 	switch runner.Subcommand {
 	case cmdInit:
@@ -110,11 +218,46 @@ func (runner *Runner) getSubcommand(base *CommandBase) (Command, error) {
 			squash := Squash{CommandBase: *base}
 			return &squash, nil
 		}
+	case cmdUnsquash:
+		{
+			unsquash := Unsquash{CommandBase: *base}
+			return &unsquash, nil
+		}
 	case cmdLog:
 		{
 			log := Log{CommandBase: *base}
 			return &log, nil
 		}
+	case cmdBaseline:
+		{
+			baseline := Baseline{CommandBase: *base}
+			return &baseline, nil
+		}
+	case cmdSeed:
+		{
+			seed := Seed{CommandBase: *base}
+			return &seed, nil
+		}
+	case cmdPlan:
+		{
+			plan := Plan{CommandBase: *base}
+			return &plan, nil
+		}
+	case cmdLint:
+		{
+			lint := Lint{CommandBase: *base}
+			return &lint, nil
+		}
+	case cmdRestore:
+		{
+			restore := Restore{CommandBase: *base}
+			return &restore, nil
+		}
+	case cmdRedo:
+		{
+			redo := Redo{CommandBase: *base}
+			return &redo, nil
+		}
 	case cmdHelp:
 		{
 			help := Help{}
@@ -139,6 +282,12 @@ func (runner *Runner) createInitFile() error {
 	credentials := flagSet.String("credentials", "", "Credentials for logging in on Postgres instance. In form username:password")
 	useSSL := flagSet.String("ssl", "disable", "Whether or not to use ssl. Defaults to disable.")
 	port := flagSet.Int("port", 5432, "Port on which PostgreSQL instance is running. Defaults to 5432")
+	archiveDir := flagSet.String("archive", "", "Directory where original files of squashed migrations are archived. Defaults to archive directory in path")
+	seedsDir := flagSet.String("seeds", "", "Directory where seed files are stored. Defaults to seeds directory in path")
+	environment := flagSet.String("env", "", "Name of the environment (for example dev, test or production) used for filtering seeds")
+	metaSchema := flagSet.String("meta-schema", "", "Schema of the meta table. Defaults to the first schema in search_path")
+	metaTable := flagSet.String("meta-table", "", "Name of the meta table storing applied migrations. Defaults to __pg_mig_meta")
+	useTemplate := flagSet.Bool("template", false, "Render migration files as Go text/template with variables from config, environment and -var flags")
 	noColor := flagSet.Bool("nocolor", false, "prevent pg-mig for printing emojis and colored text. Useful on terminals not supporting unicode.")
 	help := flagSet.Bool("help", false, "Prints help for init command")
 
@@ -168,6 +317,12 @@ func (runner *Runner) createInitFile() error {
 		SSL:         *useSSL,
 		Port:        *port,
 		NoColor:     *noColor,
+		ArchiveDir:  *archiveDir,
+		SeedsDir:    *seedsDir,
+		Environment: *environment,
+		Template:    *useTemplate,
+		MetaSchema:  *metaSchema,
+		MetaTable:   *metaTable,
 	}
 
 	err = runner.Fs.StoreConfig(config)
@@ -0,0 +1,90 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"github.com/djordjev/pg-mig/models"
+)
+
+// Seed structure for seed command
+type Seed struct {
+	CommandBase
+	isDryRun bool
+}
+
+// Run applies seeds that have not been applied yet and are allowed in current environment
+func (seed *Seed) Run() error {
+	flagSet := flag.NewFlagSet("seed", flag.ExitOnError)
+
+	env := flagSet.String("env", seed.Config.Environment, "Environment seeds are applied in. Seeds tagged for other environments are skipped. Defaults to environment from config")
+	vars := templateVars{}
+	flagSet.Var(vars, "var", "Template variable in form key=value. Can be repeated")
+	dryRun := flagSet.Bool("dry-run", false, "Run command in order to just print seeds that would be applied without actually applying them.")
+	help := flagSet.Bool("help", false, "Prints help for seed command")
+
+	err := flagSet.Parse(seed.Flags)
+	if err != nil {
+		return fmt.Errorf("seed command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	seed.isDryRun = *dryRun
+	seed.Config.CliVars = vars
+
+	// Dry-run doesn't write to database, missing seeds table means nothing has been applied
+	if !seed.isDryRun {
+		err = seed.Models.CreateSeedsTable()
+		if err != nil {
+			return err
+		}
+	}
+
+	applied, err := seed.Models.GetSeedsList()
+	if err != nil && !(seed.isDryRun && models.IsMissingTable(err)) {
+		return err
+	}
+
+	seeds, err := seed.Filesystem.GetSeeds()
+	if err != nil {
+		return err
+	}
+
+	appliedMap := make(map[int64]bool)
+	for _, ts := range applied {
+		appliedMap[ts] = true
+	}
+
+	for _, file := range seeds {
+		if appliedMap[file.Timestamp] || !file.RunsIn(*env) {
+			continue
+		}
+
+		content, err := seed.Filesystem.ReadSeedContent(file, seed.Config)
+		if err != nil {
+			return err
+		}
+
+		execContext := models.ExecutionContext{
+			Sql:       content,
+			IsUp:      true,
+			Timestamp: file.Timestamp,
+			Name:      file.Name,
+			Seed:      true,
+		}
+
+		seed.Printer.PrintUpMigration(fmt.Sprintf("Applying seed %s", execContext.Name))
+
+		if !seed.isDryRun {
+			err = seed.Models.Execute(execContext)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package subcommands
+
+import (
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSeedRun(t *testing.T) {
+	roles := filesystem.SeedFile{Timestamp: 10, Name: "seed_10_roles.sql", Content: "insert into roles values ('admin');"}
+	countries := filesystem.SeedFile{Timestamp: 20, Name: "seed_20_countries.sql", Content: "insert into countries values ('RS');", Environments: []string{"dev", "test"}}
+	users := filesystem.SeedFile{Timestamp: 30, Name: "seed_30_users.sql", Content: "insert into users values ('demo');", Environments: []string{"dev"}}
+
+	table := []struct {
+		name    string
+		config  filesystem.Config
+		flags   []string
+		applied []int64
+		// seedsErr returned when reading applied seeds
+		seedsErr error
+		expected []filesystem.SeedFile
+		printed  int
+	}{
+		{
+			name:     "applies seeds for config environment",
+			config:   filesystem.Config{Environment: "test"},
+			flags:    []string{},
+			applied:  []int64{},
+			expected: []filesystem.SeedFile{roles, countries},
+		},
+		{
+			name:     "flag overrides config environment",
+			config:   filesystem.Config{Environment: "test"},
+			flags:    []string{"-env=dev"},
+			applied:  []int64{10},
+			expected: []filesystem.SeedFile{countries, users},
+		},
+		{
+			name:     "production runs only untagged seeds",
+			config:   filesystem.Config{Environment: "production"},
+			flags:    []string{},
+			applied:  []int64{},
+			expected: []filesystem.SeedFile{roles},
+		},
+		{
+			name:     "dry run does not apply",
+			config:   filesystem.Config{Environment: "dev"},
+			flags:    []string{"-dry-run"},
+			applied:  []int64{},
+			expected: []filesystem.SeedFile{},
+			printed:  3,
+		},
+		{
+			name:     "dry run without seeds table",
+			config:   filesystem.Config{Environment: "dev"},
+			flags:    []string{"-dry-run"},
+			applied:  []int64{},
+			seedsErr: &pgconn.PgError{Code: "42P01"},
+			expected: []filesystem.SeedFile{},
+			printed:  3,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := mockedFilesystem{}
+			m := mockedModels{}
+			mp := mockedPrinter{}
+
+			mp.On("PrintUpMigration", mock.Anything)
+			if test.printed == 0 {
+				m.On("CreateSeedsTable").Return(nil).Once()
+			}
+			m.On("GetSeedsList").Return(test.applied, test.seedsErr).Once()
+			fs.On("GetSeeds").Return(filesystem.SeedFileList{roles, countries, users}, nil).Once()
+			for _, s := range []filesystem.SeedFile{roles, countries, users} {
+				fs.On("ReadSeedContent", s, mock.Anything).Return(s.Content, nil).Maybe()
+			}
+
+			for _, s := range test.expected {
+				m.On("Execute", models.ExecutionContext{
+					Sql:       s.Content,
+					IsUp:      true,
+					Timestamp: s.Timestamp,
+					Name:      s.Name,
+					Seed:      true,
+				}).Return(nil).Once()
+			}
+
+			seed := Seed{
+				CommandBase: CommandBase{
+					Config:     test.config,
+					Filesystem: &fs,
+					Models:     &m,
+					Printer:    &mp,
+					Flags:      test.flags,
+				},
+			}
+
+			r.NoError(seed.Run())
+
+			m.AssertExpectations(t)
+			fs.AssertExpectations(t)
+			m.AssertNumberOfCalls(t, "Execute", len(test.expected))
+
+			if test.printed > 0 {
+				m.AssertNotCalled(t, "CreateSeedsTable")
+				mp.AssertNumberOfCalls(t, "PrintUpMigration", test.printed)
+			}
+		})
+	}
+}
+
+func TestSeedRunRendersContent(t *testing.T) {
+	r := require.New(t)
+
+	roles := filesystem.SeedFile{Timestamp: 10, Name: "seed_10_roles.sql", Content: "insert into {{.table}} values ('admin');"}
+
+	fs := mockedFilesystem{}
+	m := mockedModels{}
+	mp := mockedPrinter{}
+
+	mp.On("PrintUpMigration", mock.Anything)
+	m.On("CreateSeedsTable").Return(nil).Once()
+	m.On("GetSeedsList").Return([]int64{}, nil).Once()
+	fs.On("GetSeeds").Return(filesystem.SeedFileList{roles}, nil).Once()
+	fs.On("ReadSeedContent", roles, mock.MatchedBy(func(config filesystem.Config) bool {
+		return config.CliVars["table"] == "roles"
+	})).Return("insert into roles values ('admin');", nil).Once()
+	m.On("Execute", models.ExecutionContext{
+		Sql:       "insert into roles values ('admin');",
+		IsUp:      true,
+		Timestamp: 10,
+		Name:      "seed_10_roles.sql",
+		Seed:      true,
+	}).Return(nil).Once()
+
+	seed := Seed{
+		CommandBase: CommandBase{
+			Config:     filesystem.Config{Template: true},
+			Filesystem: &fs,
+			Models:     &m,
+			Printer:    &mp,
+			Flags:      []string{"-var=table=roles"},
+		},
+	}
+
+	r.NoError(seed.Run())
+
+	m.AssertExpectations(t)
+	fs.AssertExpectations(t)
+}
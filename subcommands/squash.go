@@ -1,15 +1,19 @@
 package subcommands
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"path/filepath"
 	"time"
 )
 
 // Squash structure for squash command
 type Squash struct {
 	CommandBase
+	isDryRun bool
 }
 
 // Squash merges migration files into one
@@ -18,6 +22,12 @@ func (squash *Squash) Run() error {
 
 	fromStr := flagSet.String("from", "", "Time of first migration that needs to be squashed")
 	toStr := flagSet.String("to", "", "Time of the last migration that needs to be squashed")
+	fromFile := flagSet.String("from-file", "", "File name of first migration that needs to be squashed. Used instead of -from")
+	toFile := flagSet.String("to-file", "", "File name of the last migration that needs to be squashed. Used instead of -to")
+	lastCount := flagSet.Int("last", 0, "Squash the last N applied migrations. Used instead of -from and -to")
+	dryRun := flagSet.Bool("dry-run", false, "Print files that would be merged and meta table changes without modifying anything")
+	verify := flagSet.Bool("verify", false, "Verify that squashed migration produces the same schema as original ones using scratch databases")
+	backup := flagSet.Bool("backup", squash.Config.Backup, "Back up database with pg_dump before squashing")
 	help := flagSet.Bool("help", false, "Prints help for squash command")
 
 	err := flagSet.Parse(squash.Flags)
@@ -30,12 +40,18 @@ func (squash *Squash) Run() error {
 		return nil
 	}
 
-	from, err := squash.Timer.ParseTime(*fromStr)
-	if err != nil {
-		return err
+	squash.isDryRun = *dryRun
+
+	if *lastCount != 0 && (*fromStr != "" || *toStr != "" || *fromFile != "" || *toFile != "") {
+		return fmt.Errorf("squash command error: -last can't be used together with -from, -to, -from-file or -to-file")
 	}
 
-	to, err := squash.Timer.ParseTime(*toStr)
+	var from, to time.Time
+	if *lastCount != 0 {
+		from, to, err = squash.getLastBounds(*lastCount)
+	} else {
+		from, to, err = squash.getBounds(*fromStr, *toStr, *fromFile, *toFile)
+	}
 	if err != nil {
 		return err
 	}
@@ -45,22 +61,123 @@ func (squash *Squash) Run() error {
 		return err
 	}
 
+	if len(inDB) == 0 {
+		return fmt.Errorf("squash command error: no migrations found between %s and %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
 	last := inDB[len(inDB)-1]
 
-	// Safe to squash migrations
-	err = squash.Models.SquashMigrations(from, to, last)
+	if *verify {
+		err = squash.verify(migrations)
+		if err != nil {
+			return err
+		}
+	}
+
+	if squash.isDryRun {
+		squash.printPlan(migrations, from, to, last)
+		return nil
+	}
+
+	if *backup {
+		err = squash.backupDatabase("", "")
+		if err != nil {
+			return err
+		}
+	}
+
+	// Filesystem changes are rolled back on error so it's safe to start with them
+	err = squash.Filesystem.Squash(migrations)
 	if err != nil {
 		return err
 	}
 
-	err = squash.Filesystem.Squash(migrations)
+	err = squash.Models.SquashMigrations(from, to, last)
 	if err != nil {
+		_, restoreErr := squash.Filesystem.RestoreSquash(last)
+		if restoreErr != nil {
+			return fmt.Errorf("squash command error: %v and unable to restore original files %w", err, restoreErr)
+		}
+
 		return err
 	}
 
 	return nil
 }
 
+func (squash *Squash) getLastBounds(count int) (from time.Time, to time.Time, err error) {
+	if count < 2 {
+		err = fmt.Errorf("squash command error: at least 2 migrations are needed for squash, got %d", count)
+		return
+	}
+
+	inDB, err := squash.Models.GetMigrationsList()
+	if err != nil {
+		return
+	}
+
+	if count > len(inDB) {
+		err = fmt.Errorf("squash command error: requested last %d migrations but only %d are applied", count, len(inDB))
+		return
+	}
+
+	from = time.Unix(inDB[len(inDB)-count], 0)
+	to = time.Unix(inDB[len(inDB)-1], 0)
+
+	return
+}
+
+func (squash *Squash) getBounds(fromStr string, toStr string, fromFile string, toFile string) (from time.Time, to time.Time, err error) {
+	if fromFile != "" {
+		from, err = squash.getFileTime(fromFile)
+	} else {
+		from, err = squash.Timer.ParseTime(fromStr)
+	}
+	if err != nil {
+		return
+	}
+
+	if toFile != "" {
+		to, err = squash.getFileTime(toFile)
+	} else {
+		to, err = squash.Timer.ParseTime(toStr)
+	}
+
+	return
+}
+
+func (squash *Squash) getFileTime(name string) (time.Time, error) {
+	files, err := squash.Filesystem.GetFileTimestamps(time.Time{}, squash.Timer.Now())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	base := filepath.Base(name)
+	for _, file := range files {
+		if file.Up == base || file.Down == base {
+			return time.Unix(file.Timestamp, 0), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("squash command error: migration file %s does not exist in workspace", name)
+}
+
+func (squash *Squash) printPlan(migrations filesystem.MigrationFileList, from time.Time, to time.Time, last int64) {
+	for _, mig := range migrations {
+		squash.Printer.PrintUpMigration(fmt.Sprintf("Merging migration %s / %s", mig.Up, mig.Down))
+	}
+
+	up, down := migrations.SquashedFileNames()
+	squash.Printer.PrintSuccess(fmt.Sprintf("Squashed files: %s / %s", up, down))
+	squash.Printer.PrintSuccess(fmt.Sprintf(
+		"Meta table: delete %d migrations between %d and %d, insert migration %d",
+		len(migrations),
+		from.Unix(),
+		to.Unix(),
+		last,
+	))
+}
+
 func (squash *Squash) getSquash(from time.Time, to time.Time) (migrations filesystem.MigrationFileList, inDB []int64, err error) {
 	migrations, err = squash.Filesystem.GetFileTimestamps(from.Add(-1*time.Millisecond), to)
 	if err != nil {
@@ -112,3 +229,147 @@ func (squash *Squash) validate(list filesystem.MigrationFileList, inDB []int64)
 
 	return nil
 }
+
+// verify migrates two scratch databases, one with original files and one with squashed
+// migration, and compares their catalog-level schema
+func (squash *Squash) verify(migrations filesystem.MigrationFileList) error {
+	previous, err := squash.Filesystem.GetFileTimestamps(time.Time{}, time.Unix(migrations[0].Timestamp-1, 0))
+	if err != nil {
+		return err
+	}
+
+	original := make([]models.ExecutionContext, 0, len(previous)+len(migrations))
+	for _, mig := range append(previous, migrations...) {
+		content, err := squash.Filesystem.ReadMigrationContent(mig, filesystem.DirectionUp, squash.Config)
+		if err != nil {
+			return err
+		}
+
+		original = append(original, models.ExecutionContext{Sql: content, IsUp: true, Timestamp: mig.Timestamp, Name: mig.Up})
+	}
+
+	upContent, _, err := squash.Filesystem.GetSquashContent(migrations)
+	if err != nil {
+		return err
+	}
+
+	upName, _ := migrations.SquashedFileNames()
+
+	upContent, err = filesystem.RenderTemplate(upName, upContent, squash.Config)
+	if err != nil {
+		return err
+	}
+	squashed := make([]models.ExecutionContext, 0, len(previous)+1)
+	squashed = append(squashed, original[:len(previous)]...)
+	squashed = append(squashed, models.ExecutionContext{
+		Sql:       upContent,
+		IsUp:      true,
+		Timestamp: migrations[len(migrations)-1].Timestamp,
+		Name:      upName,
+	})
+
+	suffix := squash.Timer.Now().Unix()
+
+	originalSchema, err := squash.getScratchSchema(fmt.Sprintf("%s_verify_%d_original", squash.Config.DbName, suffix), original)
+	if err != nil {
+		return err
+	}
+
+	squashedSchema, err := squash.getScratchSchema(fmt.Sprintf("%s_verify_%d_squashed", squash.Config.DbName, suffix), squashed)
+	if err != nil {
+		return err
+	}
+
+	differences := diffSchemas(originalSchema, squashedSchema)
+	if len(differences) > 0 {
+		for _, diff := range differences {
+			squash.Printer.PrintError(diff)
+		}
+
+		return fmt.Errorf("squash command error: squashed migration produces different schema than original migrations (%d differences)", len(differences))
+	}
+
+	squash.Printer.PrintSuccess("Squashed migration produces the same schema as original migrations")
+
+	return nil
+}
+
+// getScratchSchema creates temporary database, executes given migrations in it and returns its schema
+func (squash *Squash) getScratchSchema(name string, migrations []models.ExecutionContext) (schema []string, err error) {
+	err = squash.Models.CreateDatabase(name)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		dropErr := squash.Models.DropDatabase(name)
+		if dropErr != nil && err == nil {
+			err = dropErr
+		}
+	}()
+
+	config := squash.Config
+	config.DbName = name
+
+	connectionString, err := config.GetConnectionString()
+	if err != nil {
+		return
+	}
+
+	policy, err := RetryPolicy(config)
+	if err != nil {
+		return
+	}
+
+	conn, err := squash.Connector(context.Background(), connectionString, policy)
+	if err != nil {
+		err = fmt.Errorf("squash command error: unable to connect on scratch database %s %w", name, err)
+		return
+	}
+	defer conn.Close(context.Background())
+
+	scratch := &models.ImplModels{Db: conn, Meta: squash.Meta}
+
+	err = scratch.CreateMetaTable()
+	if err != nil {
+		return
+	}
+
+	for _, mig := range migrations {
+		err = scratch.Execute(mig)
+		if err != nil {
+			err = fmt.Errorf("squash command error: verification failed on %s %w", name, err)
+			return
+		}
+	}
+
+	return scratch.GetSchemaSnapshot()
+}
+
+// diffSchemas returns human readable list of catalog entries present in only one of schemas
+func diffSchemas(original []string, squashed []string) []string {
+	inOriginal := make(map[string]bool)
+	for _, line := range original {
+		inOriginal[line] = true
+	}
+
+	inSquashed := make(map[string]bool)
+	for _, line := range squashed {
+		inSquashed[line] = true
+	}
+
+	result := make([]string, 0)
+	for _, line := range original {
+		if !inSquashed[line] {
+			result = append(result, "missing in squashed: "+line)
+		}
+	}
+
+	for _, line := range squashed {
+		if !inOriginal[line] {
+			result = append(result, "only in squashed: "+line)
+		}
+	}
+
+	return result
+}
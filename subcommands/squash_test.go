@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/djordjev/pg-mig/filesystem"
 	"github.com/djordjev/pg-mig/timer"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
@@ -114,3 +115,206 @@ func TestSquashRun(t *testing.T) {
 		})
 	}
 }
+
+func TestSquashRunBounds(t *testing.T) {
+	files := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 1600725600, Up: "mig_1600725600_up.sql", Down: "mig_1600725600_down.sql"},
+		filesystem.MigrationFile{Timestamp: 1600812000, Up: "mig_1600812000_up.sql", Down: "mig_1600812000_down.sql"},
+		filesystem.MigrationFile{Timestamp: 1600898400, Up: "mig_1600898400_up.sql", Down: "mig_1600898400_down.sql"},
+	}
+	inDB := []int64{1600725600, 1600812000, 1600898400}
+	now := buildGetNow("2020-10-20T15:00:00Z")
+
+	table := []struct {
+		name        string
+		flags       []string
+		from        int64
+		to          int64
+		squashed    filesystem.MigrationFileList
+		listsFiles  bool
+		dryRun      bool
+		returnError bool
+	}{
+		{
+			name:     "squashes last two",
+			flags:    []string{"-last=2"},
+			from:     1600812000,
+			to:       1600898400,
+			squashed: files[1:],
+		},
+		{
+			name:        "last requires at least two migrations",
+			flags:       []string{"-last=1"},
+			returnError: true,
+		},
+		{
+			name:        "last exceeds applied migrations",
+			flags:       []string{"-last=5"},
+			returnError: true,
+		},
+		{
+			name:        "last together with from",
+			flags:       []string{"-last=2", "-from=2020-09-22T00:00:00Z"},
+			returnError: true,
+		},
+		{
+			name:        "last together with to file",
+			flags:       []string{"-last=2", "-to-file=mig_1600898400_up.sql"},
+			returnError: true,
+		},
+		{
+			name:       "squashes by file names",
+			flags:      []string{"-from-file=mig_1600725600_up.sql", "-to-file=workspace/mig_1600812000_down.sql"},
+			from:       1600725600,
+			to:         1600812000,
+			squashed:   files[:2],
+			listsFiles: true,
+		},
+		{
+			name:        "unknown file name",
+			flags:       []string{"-from-file=mig_1_up.sql", "-to-file=mig_1600812000_up.sql"},
+			listsFiles:  true,
+			returnError: true,
+		},
+		{
+			name:     "dry run does not modify anything",
+			flags:    []string{"-last=3", "-dry-run"},
+			from:     1600725600,
+			to:       1600898400,
+			squashed: files,
+			dryRun:   true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+			mockedFS := mockedFilesystem{}
+			mockedMod := mockedModels{}
+			mp := mockedPrinter{}
+
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+
+			squash := Squash{
+				CommandBase: CommandBase{
+					Filesystem: &mockedFS,
+					Flags:      test.flags,
+					Models:     &mockedMod,
+					Printer:    &mp,
+					Timer:      timer.Timer{Now: now},
+				},
+			}
+
+			mockedMod.On("GetMigrationsList").Return(inDB, nil)
+
+			if test.listsFiles {
+				mockedFS.On("GetFileTimestamps", time.Time{}, now()).Return(files, nil)
+			}
+
+			if test.squashed != nil {
+				from := time.Unix(test.from, 0)
+				to := time.Unix(test.to, 0)
+				mockedFS.On("GetFileTimestamps", from.Add(-1*time.Millisecond), to).Return(test.squashed, nil).Once()
+
+				if !test.dryRun {
+					mockedFS.On("Squash", test.squashed).Return(nil).Once()
+					mockedMod.On("SquashMigrations", from, to, test.to).Return(nil).Once()
+				}
+			}
+
+			err := squash.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			mockedFS.AssertExpectations(t)
+			if test.dryRun {
+				mockedFS.AssertNotCalled(t, "Squash", mock.Anything)
+				mockedMod.AssertNotCalled(t, "SquashMigrations", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestSquashRunRestoresFilesOnDBError(t *testing.T) {
+	r := require.New(t)
+	files := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 1600725600, Up: "mig_1600725600_up.sql", Down: "mig_1600725600_down.sql"},
+		filesystem.MigrationFile{Timestamp: 1600812000, Up: "mig_1600812000_up.sql", Down: "mig_1600812000_down.sql"},
+	}
+
+	mockedFS := mockedFilesystem{}
+	mockedMod := mockedModels{}
+
+	from := time.Unix(1600725600, 0)
+	to := time.Unix(1600812000, 0)
+
+	mockedMod.On("GetMigrationsList").Return([]int64{1600725600, 1600812000}, nil)
+	mockedFS.On("GetFileTimestamps", from.Add(-1*time.Millisecond), to).Return(files, nil).Once()
+	mockedFS.On("Squash", files).Return(nil).Once()
+	mockedMod.On("SquashMigrations", from, to, int64(1600812000)).Return(errors.New("db error")).Once()
+	mockedFS.On("RestoreSquash", int64(1600812000)).Return(files, nil).Once()
+
+	squash := Squash{
+		CommandBase: CommandBase{
+			Filesystem: &mockedFS,
+			Flags:      []string{"-last=2"},
+			Models:     &mockedMod,
+			Timer:      timer.Timer{Now: buildGetNow("2020-10-20T15:00:00Z")},
+		},
+	}
+
+	r.Error(squash.Run())
+
+	mockedFS.AssertExpectations(t)
+	mockedMod.AssertExpectations(t)
+}
+
+func TestDiffSchemas(t *testing.T) {
+	r := require.New(t)
+
+	r.Empty(diffSchemas([]string{"table a", "table b"}, []string{"table a", "table b"}))
+
+	diff := diffSchemas([]string{"table a", "table b"}, []string{"table a", "table c"})
+	r.Equal([]string{"missing in squashed: table b", "only in squashed: table c"}, diff)
+}
+
+func TestSquashRunVerifyFails(t *testing.T) {
+	r := require.New(t)
+	files := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 1600725600, Up: "mig_1600725600_up.sql", Down: "mig_1600725600_down.sql"},
+		filesystem.MigrationFile{Timestamp: 1600812000, Up: "mig_1600812000_up.sql", Down: "mig_1600812000_down.sql"},
+	}
+
+	mockedFS := mockedFilesystem{}
+	mockedMod := mockedModels{}
+
+	from := time.Unix(1600725600, 0)
+	to := time.Unix(1600812000, 0)
+	now := buildGetNow("2020-10-20T15:00:00Z")
+
+	mockedMod.On("GetMigrationsList").Return([]int64{1600725600, 1600812000}, nil)
+	mockedFS.On("GetFileTimestamps", from.Add(-1*time.Millisecond), to).Return(files, nil).Once()
+	mockedFS.On("GetFileTimestamps", time.Time{}, time.Unix(1600725599, 0)).Return(filesystem.MigrationFileList{}, nil).Once()
+	mockedFS.On("ReadMigrationContent", mock.Anything, mock.Anything, mock.Anything).Return("sql", nil)
+	mockedFS.On("GetSquashContent", files).Return("sql", "sql", nil).Once()
+	mockedMod.On("CreateDatabase", mock.Anything).Return(errors.New("permission denied")).Once()
+
+	squash := Squash{
+		CommandBase: CommandBase{
+			Filesystem: &mockedFS,
+			Flags:      []string{"-last=2", "-verify"},
+			Models:     &mockedMod,
+			Timer:      timer.Timer{Now: now},
+		},
+	}
+
+	r.Error(squash.Run())
+
+	mockedFS.AssertExpectations(t)
+	mockedFS.AssertNotCalled(t, "Squash", mock.Anything)
+	mockedMod.AssertNotCalled(t, "SquashMigrations", mock.Anything, mock.Anything, mock.Anything)
+}
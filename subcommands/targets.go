@@ -0,0 +1,177 @@
+package subcommands
+
+import (
+	"context"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"strings"
+	"sync"
+)
+
+const allTargets = "all"
+
+// targetResult outcome of running migrations against a single target database
+type targetResult struct {
+	name     string
+	executed int
+	err      error
+}
+
+// getTargets returns targets selected by comma separated names (or all) from the
+// targets file when provided, otherwise from config. Empty result means a regular run.
+func (run *Run) getTargets(names string, file string) ([]filesystem.Target, error) {
+	if names == "" && file == "" {
+		return nil, nil
+	}
+
+	available := run.Config.Targets
+
+	if file != "" {
+		loaded, err := run.Filesystem.LoadTargets(file)
+		if err != nil {
+			return nil, err
+		}
+
+		available = loaded
+
+		if names == "" {
+			names = allTargets
+		}
+	}
+
+	if names == allTargets {
+		if len(available) == 0 {
+			return nil, fmt.Errorf("run command error: no targets defined")
+		}
+
+		return available, nil
+	}
+
+	byName := make(map[string]filesystem.Target)
+	for _, target := range available {
+		byName[target.Name] = target
+	}
+
+	result := make([]filesystem.Target, 0, len(available))
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		target, exists := byName[name]
+		if !exists {
+			return nil, fmt.Errorf("run command error: unknown target %s", name)
+		}
+
+		result = append(result, target)
+	}
+
+	return result, nil
+}
+
+// migrateTargets runs migrations against each target with at most concurrency targets
+// at a time and prints the final success/failure matrix
+func (run *Run) migrateTargets(strTime *string, targets []filesystem.Target, concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("run command error: concurrency must be positive, got %d", concurrency)
+	}
+
+	if run.Connector == nil {
+		return fmt.Errorf("run command error: connecting to targets is not supported")
+	}
+
+	results := make([]targetResult, len(targets))
+	mutex := &sync.Mutex{}
+	slots := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for i, target := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, target filesystem.Target) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			printer := &prefixedPrinter{prefix: fmt.Sprintf("[%s] ", target.Name), printer: run.Printer, mutex: mutex}
+			results[i] = run.migrateTarget(strTime, target, printer)
+		}(i, target)
+	}
+
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			run.Printer.PrintError(fmt.Sprintf("%s: FAILED after %d migrations: %v", result.name, result.executed, result.err))
+			continue
+		}
+
+		run.Printer.PrintSuccess(fmt.Sprintf("%s: OK, %d migrations executed", result.name, result.executed))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("run command error: migrations failed on %d of %d targets", failed, len(targets))
+	}
+
+	return nil
+}
+
+func (run *Run) migrateTarget(strTime *string, target filesystem.Target, printer Printer) (result targetResult) {
+	result = targetResult{name: target.Name}
+
+	config := target.Apply(run.Config)
+
+	connectionString, err := config.GetConnectionString()
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	policy, err := RetryPolicy(config)
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	conn, err := run.Connector(context.Background(), connectionString, policy)
+	if err != nil {
+		result.err = fmt.Errorf("run command error: unable to connect on target %s %w", target.Name, err)
+		return
+	}
+	defer func() {
+		err := conn.Close(context.Background())
+		if err != nil && result.err == nil {
+			result.err = fmt.Errorf("run command error: unable to close connection to target %s %w", target.Name, err)
+		}
+	}()
+
+	targetRun := *run
+	targetRun.Config = config
+	targetRun.Models = &models.ImplModels{Db: conn, Meta: run.Meta}
+	targetRun.Printer = printer
+	targetRun.targetName = target.Name
+	// Targets run concurrently so destructive changes can't be confirmed interactively
+	targetRun.Input = nil
+	targetRun.answers = nil
+	targetRun.executed = 0
+	targetRun.timings = nil
+
+	if !targetRun.isDryRun {
+		err = targetRun.Models.CreateMetaTable()
+	} else {
+		err = prepareMetaTable(targetRun.Models, true)
+	}
+	if err != nil {
+		result.err = err
+		return
+	}
+
+	result.err = targetRun.migrate(strTime)
+	result.executed = targetRun.executed
+
+	return
+}
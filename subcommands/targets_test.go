@@ -0,0 +1,182 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// emptyConnection database connection with no applied migrations
+type emptyConnection struct {
+	connection
+}
+
+func (c emptyConnection) Query(_ context.Context, _ string, _ ...interface{}) (pgx.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Close()                                         {}
+func (emptyRows) Err() error                                     { return nil }
+func (emptyRows) CommandTag() pgconn.CommandTag                  { return nil }
+func (emptyRows) FieldDescriptions() []pgproto3.FieldDescription { return nil }
+func (emptyRows) Next() bool                                     { return false }
+func (emptyRows) Scan(_ ...interface{}) error                    { return nil }
+func (emptyRows) Values() ([]interface{}, error)                 { return nil, nil }
+func (emptyRows) RawValues() [][]byte                            { return nil }
+
+func TestRunTargets(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	targets := []filesystem.Target{
+		{Name: "shard_1", DbName: "shard_1"},
+		{Name: "shard_2", DbName: "shard_2"},
+		{Name: "shard_3", DbName: "shard_3", DbURL: "unreachable"},
+	}
+
+	table := []struct {
+		name        string
+		flags       []string
+		fileTargets []filesystem.Target
+		connected   []string
+		success     []string
+		failure     []string
+		returnError bool
+	}{
+		{
+			name:      "selected targets from config",
+			flags:     []string{"-dry-run", "-targets=shard_1,shard_2"},
+			connected: []string{"shard_1", "shard_2"},
+			success:   []string{"shard_1: OK, 2 migrations executed", "shard_2: OK, 2 migrations executed"},
+		},
+		{
+			name:        "all targets with one failing",
+			flags:       []string{"-dry-run", "-targets=all", "-concurrency=1"},
+			connected:   []string{"shard_1", "shard_2"},
+			success:     []string{"shard_1: OK, 2 migrations executed", "shard_2: OK, 2 migrations executed"},
+			failure:     []string{"shard_3: FAILED after 0 migrations: run command error: unable to connect on target shard_3 connection refused"},
+			returnError: true,
+		},
+		{
+			name:        "targets file",
+			flags:       []string{"-dry-run", "-targets-file=shards.json"},
+			fileTargets: []filesystem.Target{{Name: "shard_9", DbName: "shard_9"}},
+			connected:   []string{"shard_9"},
+			success:     []string{"shard_9: OK, 2 migrations executed"},
+		},
+		{
+			name:        "unknown target",
+			flags:       []string{"-targets=shard_7"},
+			returnError: true,
+		},
+		{
+			name:        "targets with tenant schemas",
+			flags:       []string{"-targets=all", "-schemas-query=select nspname from pg_namespace"},
+			returnError: true,
+		},
+		{
+			name:        "invalid concurrency",
+			flags:       []string{"-targets=all", "-concurrency=0"},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("mig_1_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte("mig_2_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("mig_2_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+			_ = afero.WriteFile(fs, "shards.json", []byte(`[{"name": "shard_9", "db_name": "shard_9"}]`), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			mutex := sync.Mutex{}
+			connected := make([]string, 0)
+
+			connector := func(ctx context.Context, str string, policy models.RetryPolicy) (models.DBConnection, error) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if strings.HasPrefix(str, "postgres://u:p@unreachable:") {
+					return nil, errors.New("connection refused")
+				}
+
+				connected = append(connected, strings.TrimPrefix(str[:strings.Index(str, "?")], "postgres://u:p@localhost:5432/"))
+
+				return &emptyConnection{}, nil
+			}
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			for _, text := range test.success {
+				mp.On("PrintSuccess", text).Once()
+			}
+			for _, text := range test.failure {
+				mp.On("PrintError", text).Once()
+			}
+
+			run := Run{
+				CommandBase: CommandBase{
+					Config:     filesystem.Config{DbURL: "localhost", Credentials: "u:p", Port: 5432, SSL: "disable", Targets: targets},
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &mockedModels{},
+					Flags:      test.flags,
+					Printer:    &mp,
+					Connector:  connector,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			for _, text := range test.success {
+				mp.AssertCalled(t, "PrintSuccess", text)
+			}
+			for _, text := range test.failure {
+				mp.AssertCalled(t, "PrintError", text)
+			}
+
+			r.ElementsMatch(test.connected, connected)
+		})
+	}
+}
+
+func TestPrefixedPrinter(t *testing.T) {
+	mp := mockedPrinter{}
+	mp.On("PrintUpMigration", "[shard_1] up").Once()
+	mp.On("PrintDownMigration", "[shard_1] down").Once()
+	mp.On("PrintMigrations", "[shard_1] 2020-10-20", "fs", "db").Once()
+
+	p := prefixedPrinter{prefix: "[shard_1] ", printer: &mp, mutex: &sync.Mutex{}}
+	p.PrintUpMigration("up")
+	p.PrintDownMigration("down")
+	p.PrintMigrations("2020-10-20", "fs", "db")
+
+	mp.AssertExpectations(t)
+}
@@ -0,0 +1,153 @@
+package subcommands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/djordjev/pg-mig/models"
+)
+
+const (
+	tenantFailureStop     = "stop"
+	tenantFailureContinue = "continue"
+)
+
+// tenantResult outcome of running migrations in a single tenant schema
+type tenantResult struct {
+	schema   string
+	executed int
+	err      error
+}
+
+// getTenantSchemas returns explicitly listed schemas or, when the list is empty,
+// schemas returned by the query. Empty result means a regular single schema run.
+func (run *Run) getTenantSchemas(list string, query string) ([]string, error) {
+	if list != "" && query != "" {
+		return nil, fmt.Errorf("run command error: schemas list and schemas query can't be used together")
+	}
+
+	if query != "" {
+		schemas, err := run.Models.ListSchemas(query)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(schemas) == 0 {
+			return nil, fmt.Errorf("run command error: schemas query returned no schemas")
+		}
+
+		return schemas, nil
+	}
+
+	schemas := make([]string, 0, 10)
+	for _, schema := range strings.Split(list, ",") {
+		schema = strings.TrimSpace(schema)
+		if schema != "" {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	return schemas, nil
+}
+
+// migrateTenants runs migrations in each schema with its own meta table
+// and prints per tenant summary. On failure it either stops or continues
+// with the next tenant depending on policy.
+func (run *Run) migrateTenants(strTime *string, schemas []string, policy string) error {
+	if policy != tenantFailureStop && policy != tenantFailureContinue {
+		return fmt.Errorf("run command error: unknown failure policy %s, expected %s or %s", policy, tenantFailureStop, tenantFailureContinue)
+	}
+
+	results := make([]tenantResult, 0, len(schemas))
+
+	for _, schema := range schemas {
+		run.Printer.PrintSuccess(fmt.Sprintf("Migrating schema %s", schema))
+
+		result := run.migrateTenant(strTime, schema)
+		results = append(results, result)
+
+		if result.err != nil {
+			run.Printer.PrintError(fmt.Sprintf("Schema %s failed: %v", schema, result.err))
+
+			if policy == tenantFailureStop {
+				break
+			}
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			run.Printer.PrintError(fmt.Sprintf("%s: failed after %d migrations", result.schema, result.executed))
+			continue
+		}
+
+		run.Printer.PrintSuccess(fmt.Sprintf("%s: %d migrations executed", result.schema, result.executed))
+	}
+
+	skipped := len(schemas) - len(results)
+	if skipped > 0 {
+		run.Printer.PrintError(fmt.Sprintf("%d schemas skipped", skipped))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("run command error: migrations failed in %d of %d schemas", failed, len(schemas))
+	}
+
+	return nil
+}
+
+// unmigratedModels models of tenant schema without meta table. It reports that
+// nothing has been applied so that dry-run lists all migrations.
+type unmigratedModels struct {
+	models.Models
+}
+
+func (m unmigratedModels) GetMigrationsList() ([]int64, error) {
+	return []int64{}, nil
+}
+
+func (m unmigratedModels) GetRepeatableChecksums() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (run *Run) migrateTenant(strTime *string, schema string) tenantResult {
+	result := tenantResult{schema: schema}
+
+	tenantModels, err := run.Models.InSchema(schema)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	tenant := *run
+	tenant.Models = tenantModels
+	tenant.Meta.Schema = schema
+	tenant.tenantSchema = schema
+	tenant.executed = 0
+	tenant.timings = nil
+
+	if !tenant.isDryRun {
+		err = tenant.Models.CreateMetaTable()
+	} else {
+		err = prepareMetaTable(tenant.Models, true)
+	}
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	if tenant.isDryRun {
+		_, err = tenant.Models.GetMigrationsList()
+		if models.IsMissingTable(err) {
+			// Meta table (or the whole schema) is created on the first real run
+			tenant.Models = unmigratedModels{Models: tenantModels}
+		}
+	}
+
+	result.err = tenant.migrate(strTime)
+	result.executed = tenant.executed
+
+	return result
+}
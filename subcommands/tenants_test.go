@@ -0,0 +1,163 @@
+package subcommands
+
+import (
+	"errors"
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/jackc/pgconn"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunTenants(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	query := "select nspname from pg_namespace where nspname like 'tenant_%'"
+
+	table := []struct {
+		name        string
+		config      filesystem.Config
+		flags       []string
+		failing     string
+		missing     string
+		executed    map[string]int
+		summary     []string
+		returnError bool
+	}{
+		{
+			name:     "explicit list of schemas",
+			flags:    []string{"-schemas=tenant_a, tenant_b"},
+			executed: map[string]int{"tenant_a": 1, "tenant_b": 2},
+		},
+		{
+			name:     "schemas from query in config",
+			config:   filesystem.Config{SchemasQuery: query},
+			flags:    []string{},
+			executed: map[string]int{"tenant_a": 1, "tenant_b": 2},
+		},
+		{
+			name:        "stop on failure",
+			flags:       []string{"-schemas=tenant_a,tenant_b"},
+			failing:     "tenant_a",
+			executed:    map[string]int{"tenant_a": 1, "tenant_b": 0},
+			returnError: true,
+		},
+		{
+			name:        "continue on failure",
+			config:      filesystem.Config{OnTenantFailure: "continue"},
+			flags:       []string{"-schemas=tenant_a,tenant_b"},
+			failing:     "tenant_a",
+			executed:    map[string]int{"tenant_a": 1, "tenant_b": 2},
+			returnError: true,
+		},
+		{
+			name:     "dry run of schema without meta table",
+			flags:    []string{"-schemas=tenant_a,tenant_b", "-dry-run"},
+			missing:  "tenant_b",
+			executed: map[string]int{"tenant_a": 0, "tenant_b": 0},
+			summary:  []string{"tenant_a: 1 migrations executed", "tenant_b: 2 migrations executed"},
+		},
+		{
+			name:        "missing meta table fails real run",
+			flags:       []string{"-schemas=tenant_a,tenant_b"},
+			missing:     "tenant_b",
+			executed:    map[string]int{"tenant_a": 1, "tenant_b": 0},
+			returnError: true,
+		},
+		{
+			name:        "unknown policy",
+			flags:       []string{"-schemas=tenant_a", "-on-failure=retry"},
+			executed:    map[string]int{"tenant_a": 0, "tenant_b": 0},
+			returnError: true,
+		},
+		{
+			name:        "list and query together",
+			flags:       []string{"-schemas=tenant_a", "-schemas-query=" + query},
+			executed:    map[string]int{"tenant_a": 0, "tenant_b": 0},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t1.Unix()), []byte("mig_1_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("mig_1_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_up.sql", t2.Unix()), []byte("mig_2_up_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("mig_2_down_sql"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			getNow := buildGetNow("2020-10-22T10:04:00Z")
+
+			tenants := map[string]*mockedModels{
+				"tenant_a": {},
+				"tenant_b": {},
+			}
+
+			tenants["tenant_a"].On("GetMigrationsList").Return([]int64{t1.Unix()}, nil)
+			tenants["tenant_b"].On("GetMigrationsList").Return([]int64{}, nil)
+
+			if test.missing != "" {
+				tenants[test.missing] = &mockedModels{}
+				tenants[test.missing].On("GetMigrationsList").Return([]int64{}, &pgconn.PgError{Code: "42P01"})
+			}
+
+			for schema, tenant := range tenants {
+				var executeErr error
+				if schema == test.failing {
+					executeErr = errors.New("db error")
+				}
+
+				tenant.On("Execute", mock.Anything).Return(executeErr)
+			}
+
+			m := mockedModels{}
+			m.On("ListSchemas", query).Return([]string{"tenant_a", "tenant_b"}, nil)
+			m.On("InSchema", "tenant_a").Return(tenants["tenant_a"], nil)
+			m.On("InSchema", "tenant_b").Return(tenants["tenant_b"], nil)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			printed := make([]string, 0)
+			mp.On("PrintSuccess", mock.Anything).Run(func(args mock.Arguments) {
+				printed = append(printed, args.String(0))
+			})
+			mp.On("PrintError", mock.Anything)
+
+			run := Run{
+				CommandBase: CommandBase{
+					Config:     test.config,
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			m.AssertNotCalled(t, "Execute", mock.Anything)
+			for schema, tenant := range tenants {
+				tenant.AssertNumberOfCalls(t, "Execute", test.executed[schema])
+			}
+
+			for _, summary := range test.summary {
+				r.Contains(printed, summary)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package subcommands
+
+import (
+	"fmt"
+	"time"
+)
+
+// migrationTiming execution time of a single migration
+type migrationTiming struct {
+	name     string
+	duration time.Duration
+}
+
+func (timing migrationTiming) isSlow(threshold time.Duration) bool {
+	return threshold > 0 && timing.duration > threshold
+}
+
+// recordTiming prints duration of executed migration and warns when it exceeds slow threshold
+func (run *Run) recordTiming(name string, duration time.Duration) {
+	timing := migrationTiming{name: name, duration: duration}
+	run.timings = append(run.timings, timing)
+
+	run.Printer.PrintSuccess(fmt.Sprintf("%s done in %s", name, duration.Round(time.Millisecond)))
+
+	if timing.isSlow(run.slowThreshold) {
+		run.Printer.PrintError(fmt.Sprintf("%s took %s, exceeding slow migration threshold of %s", name, duration.Round(time.Millisecond), run.slowThreshold))
+	}
+}
+
+// reportTimings prints summary table with durations of executed migrations. Returns error
+// when some of them were slow and the run should fail because of it.
+func (run *Run) reportTimings() error {
+	if len(run.timings) == 0 {
+		return nil
+	}
+
+	width := 0
+	for _, timing := range run.timings {
+		if len(timing.name) > width {
+			width = len(timing.name)
+		}
+	}
+
+	var total time.Duration
+	slow := 0
+
+	run.Printer.PrintSuccess("Migration durations:")
+
+	for _, timing := range run.timings {
+		total += timing.duration
+
+		mark := ""
+		if timing.isSlow(run.slowThreshold) {
+			slow++
+			mark = " SLOW"
+		}
+
+		run.Printer.PrintSuccess(fmt.Sprintf("  %-*s %10s%s", width, timing.name, timing.duration.Round(time.Millisecond), mark))
+	}
+
+	run.Printer.PrintSuccess(fmt.Sprintf("  %-*s %10s", width, "total", total.Round(time.Millisecond)))
+
+	if slow > 0 && run.failOnSlow {
+		return fmt.Errorf("run command error: %d migrations exceeded slow migration threshold of %s", slow, run.slowThreshold)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package subcommands
+
+import (
+	"fmt"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRunTimings(t *testing.T) {
+	t1, _ := time.Parse(time.RFC3339, "2020-10-20T10:00:00Z")
+	t2, _ := time.Parse(time.RFC3339, "2020-10-21T10:00:00Z")
+
+	name1 := fmt.Sprintf("mig_%d_up.sql", t1.Unix())
+	name2 := fmt.Sprintf("mig_%d_up.sql", t2.Unix())
+
+	table := []struct {
+		name        string
+		config      filesystem.Config
+		flags       []string
+		slow        []string
+		returnError bool
+	}{
+		{
+			name:  "prints durations without threshold",
+			flags: []string{},
+		},
+		{
+			name:  "warns about slow migrations",
+			flags: []string{"-slow=5s"},
+			slow:  []string{name1, name2},
+		},
+		{
+			name:        "fails on slow migrations",
+			flags:       []string{"-slow=5s", "-fail-on-slow"},
+			slow:        []string{name1, name2},
+			returnError: true,
+		},
+		{
+			name:        "threshold from config",
+			config:      filesystem.Config{SlowThreshold: "5s", FailOnSlow: true},
+			flags:       []string{},
+			slow:        []string{name1, name2},
+			returnError: true,
+		},
+		{
+			name:   "no warnings under threshold",
+			config: filesystem.Config{SlowThreshold: "1m", FailOnSlow: true},
+			flags:  []string{},
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, name1, []byte("create table a();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t1.Unix()), []byte("select 1;"), os.ModePerm)
+			_ = afero.WriteFile(fs, name2, []byte("create table b();"), os.ModePerm)
+			_ = afero.WriteFile(fs, fmt.Sprintf("mig_%d_down.sql", t2.Unix()), []byte("select 1;"), os.ModePerm)
+			_ = afero.WriteFile(fs, "pgmig.config.json", []byte(validContent), os.ModePerm)
+
+			// Every reading of the clock moves it 10 seconds forward
+			now, _ := time.Parse(time.RFC3339, "2020-10-22T10:04:00Z")
+			getNow := func() time.Time {
+				now = now.Add(10 * time.Second)
+				return now
+			}
+
+			m := mockedModels{}
+			m.On("GetMigrationsList").Return([]int64{}, nil)
+			m.On("Execute", mock.Anything).Return(nil)
+
+			mp := mockedPrinter{}
+			mp.On("PrintUpMigration", mock.Anything)
+			mp.On("PrintSuccess", mock.Anything)
+			mp.On("PrintError", mock.Anything)
+
+			run := Run{
+				CommandBase: CommandBase{
+					Config:     test.config,
+					Filesystem: &filesystem.ImplFilesystem{Fs: fs, GetNow: getNow},
+					Timer:      timer.Timer{Now: getNow},
+					Models:     &m,
+					Flags:      test.flags,
+					Printer:    &mp,
+				},
+			}
+
+			err := run.Run()
+			if test.returnError {
+				r.Error(err)
+				r.Contains(err.Error(), "2 migrations exceeded slow migration threshold of 5s")
+			} else {
+				r.NoError(err)
+			}
+
+			mp.AssertCalled(t, "PrintSuccess", name1+" done in 10s")
+			mp.AssertCalled(t, "PrintSuccess", "Migration durations:")
+			mp.AssertCalled(t, "PrintSuccess", fmt.Sprintf("  %-21s %10s", "total", "20s"))
+
+			for _, name := range []string{name1, name2} {
+				if contains(test.slow, name) {
+					mp.AssertCalled(t, "PrintError", fmt.Sprintf("%s took 10s, exceeding slow migration threshold of 5s", name))
+					mp.AssertCalled(t, "PrintSuccess", fmt.Sprintf("  %s        10s SLOW", name))
+				} else {
+					mp.AssertCalled(t, "PrintSuccess", fmt.Sprintf("  %s        10s", name))
+				}
+			}
+
+			if len(test.slow) == 0 {
+				mp.AssertNotCalled(t, "PrintError", mock.Anything)
+			}
+		})
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/djordjev/pg-mig/filesystem"
 	"github.com/djordjev/pg-mig/models"
 	"github.com/djordjev/pg-mig/timer"
+	"io"
 )
 
 // Command interface encapsulating different commands
@@ -20,10 +21,30 @@ type CommandBase struct {
 	Filesystem filesystem.Filesystem
 	Timer      timer.Timer
 	Printer    Printer
+	Dumper     Dumper
+	Connector  DBConnector
+	Meta       models.MetaTable
+	// Input used for interactive confirmations, nil when running non-interactively
+	Input io.Reader
+	// AllowDestructive executes down migrations and destructive statements without confirmation
+	AllowDestructive bool
+
+	GoMigrations map[int64]models.GoMigration
+	Hooks        Hooks
+	// Tracer and Metrics instrument executed migrations, nil disables them
+	Tracer  Tracer
+	Metrics Metrics
 }
 
-// DBConnector interface for opening DB connection
-type DBConnector func(ctx context.Context, connString string) (models.DBConnection, error)
+// DBConnector interface for opening DB connection, connecting is retried according to policy
+type DBConnector func(ctx context.Context, connString string, policy models.RetryPolicy) (models.DBConnection, error)
+
+// Dumper interface for dumping database contents with external tools
+type Dumper interface {
+	DumpSchema(connString string) (string, error)
+	Backup(connString string, schema string, file string) error
+	Restore(connString string, file string) error
+}
 
 const (
 	PUSH = "push"
@@ -0,0 +1,83 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Unsquash structure for unsquash command
+type Unsquash struct {
+	CommandBase
+}
+
+// Run restores original migration files of a squashed migration from archive
+func (unsquash *Unsquash) Run() error {
+	flagSet := flag.NewFlagSet("unsquash", flag.ExitOnError)
+
+	strTime := flagSet.String("time", "", "Time of squashed migration that needs to be restored")
+	help := flagSet.Bool("help", false, "Prints help for unsquash command")
+
+	err := flagSet.Parse(unsquash.Flags)
+	if err != nil {
+		return fmt.Errorf("unsquash command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	squashTime, err := unsquash.Timer.ParseTime(*strTime)
+	if err != nil {
+		return err
+	}
+	ts := squashTime.Unix()
+
+	inDB, err := unsquash.Models.GetMigrationsList()
+	if err != nil {
+		return err
+	}
+
+	restored, err := unsquash.Filesystem.RestoreSquash(ts)
+	if err != nil {
+		return err
+	}
+
+	if !containsTimestamp(inDB, ts) {
+		// Squashed migration is not applied so there's nothing to update in meta table
+		unsquash.Printer.PrintSuccess(fmt.Sprintf("Restored %d migrations", len(restored)))
+		return nil
+	}
+
+	// Squashed migration shares timestamp with the last restored one which stays in meta table
+	toMark := make([]int64, 0, len(restored))
+	for _, mig := range restored {
+		if mig.Timestamp != ts {
+			toMark = append(toMark, mig.Timestamp)
+		}
+	}
+
+	err = unsquash.Models.MarkMigrations(toMark)
+	if err != nil {
+		squashErr := unsquash.Filesystem.Squash(restored)
+		if squashErr != nil {
+			return fmt.Errorf("unsquash command error: %v and unable to squash files again %w", err, squashErr)
+		}
+
+		return err
+	}
+
+	unsquash.Printer.PrintSuccess(fmt.Sprintf("Restored %d migrations", len(restored)))
+
+	return nil
+}
+
+func containsTimestamp(list []int64, ts int64) bool {
+	for _, v := range list {
+		if v == ts {
+			return true
+		}
+	}
+
+	return false
+}
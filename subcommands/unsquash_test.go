@@ -0,0 +1,98 @@
+package subcommands
+
+import (
+	"errors"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUnsquashRun(t *testing.T) {
+	restored := filesystem.MigrationFileList{
+		filesystem.MigrationFile{Timestamp: 100, Up: "mig_100_up.sql", Down: "mig_100_down.sql"},
+		filesystem.MigrationFile{Timestamp: 200, Up: "mig_200_up.sql", Down: "mig_200_down.sql"},
+		filesystem.MigrationFile{Timestamp: 300, Up: "mig_300_up.sql", Down: "mig_300_down.sql"},
+	}
+
+	table := []struct {
+		name        string
+		inDB        []int64
+		restoreErr  error
+		marked      []int64
+		markErr     error
+		resquash    bool
+		returnError bool
+	}{
+		{
+			name:   "restores applied squash",
+			inDB:   []int64{50, 300},
+			marked: []int64{100, 200},
+		},
+		{
+			name: "restores squash not applied",
+			inDB: []int64{50},
+		},
+		{
+			name:        "restore fails",
+			inDB:        []int64{50, 300},
+			restoreErr:  errors.New("fs error"),
+			returnError: true,
+		},
+		{
+			name:        "meta table update fails",
+			inDB:        []int64{50, 300},
+			marked:      []int64{100, 200},
+			markErr:     errors.New("db error"),
+			resquash:    true,
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+			fs := mockedFilesystem{}
+			models := mockedModels{}
+			mp := mockedPrinter{}
+
+			mp.On("PrintSuccess", mock.Anything)
+			models.On("GetMigrationsList").Return(test.inDB, nil)
+
+			if test.restoreErr != nil {
+				fs.On("RestoreSquash", int64(300)).Return(filesystem.MigrationFileList{}, test.restoreErr).Once()
+			} else {
+				fs.On("RestoreSquash", int64(300)).Return(restored, nil).Once()
+			}
+
+			if test.marked != nil {
+				models.On("MarkMigrations", test.marked).Return(test.markErr).Once()
+			}
+
+			if test.resquash {
+				fs.On("Squash", restored).Return(nil).Once()
+			}
+
+			unsquash := Unsquash{
+				CommandBase: CommandBase{
+					Filesystem: &fs,
+					Models:     &models,
+					Printer:    &mp,
+					Flags:      []string{"-time=300"},
+					Timer:      timer.Timer{Now: buildGetNow("2020-10-20T15:00:00Z")},
+				},
+			}
+
+			err := unsquash.Run()
+			if test.returnError {
+				r.Error(err)
+			} else {
+				r.NoError(err)
+			}
+
+			fs.AssertExpectations(t)
+			models.AssertExpectations(t)
+		})
+	}
+}
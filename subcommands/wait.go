@@ -0,0 +1,110 @@
+package subcommands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+)
+
+// Wait structure for wait command
+type Wait struct {
+	CommandBase
+}
+
+// Run blocks until database is reachable or timeout expires
+func (wait *Wait) Run() error {
+	flagSet := flag.NewFlagSet("wait", flag.ExitOnError)
+
+	policy, err := RetryPolicy(wait.Config)
+	if err != nil {
+		return err
+	}
+
+	defaultTimeout := time.Minute
+	if policy.MaxWait > 0 {
+		defaultTimeout = policy.MaxWait
+	}
+
+	timeout := flagSet.Duration("timeout", defaultTimeout, "Maximum time to wait for database. 0 waits until database is reachable")
+	interval := flagSet.Duration("interval", policy.Backoff, "Delay after the first failed connection attempt, doubled after each next one up to 30s")
+	help := flagSet.Bool("help", false, "Prints help for wait command")
+
+	err = flagSet.Parse(wait.Flags)
+	if err != nil {
+		return fmt.Errorf("wait command error: unable to parse program flags %w", err)
+	}
+
+	if help != nil && *help == true {
+		flagSet.PrintDefaults()
+		return nil
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("wait command error: interval must be positive, got %s", *interval)
+	}
+
+	if *timeout < 0 {
+		return fmt.Errorf("wait command error: timeout can't be negative, got %s", *timeout)
+	}
+
+	connectionString, err := wait.Config.GetConnectionString()
+	if err != nil {
+		return err
+	}
+
+	start := wait.Timer.Now()
+
+	// Number of attempts is not limited, waiting is bounded only by timeout
+	policy = models.RetryPolicy{Backoff: *interval, MaxWait: *timeout}
+
+	conn, err := wait.Connector(context.Background(), connectionString, policy)
+	if err != nil {
+		return fmt.Errorf("wait command error: database %s is not reachable %w", wait.Config.DbName, err)
+	}
+
+	err = conn.Close(context.Background())
+	if err != nil {
+		return fmt.Errorf("wait command error: unable to close connection %w", err)
+	}
+
+	waited := wait.Timer.Now().Sub(start).Round(time.Millisecond)
+	wait.Printer.PrintSuccess(fmt.Sprintf("Database %s is ready after %s", wait.Config.DbName, waited))
+
+	return nil
+}
+
+// RetryPolicy returns policy for connecting on database from config. Values that are not
+// set are taken from models.DefaultRetryPolicy, except that number of attempts is not
+// limited when only max wait is configured.
+func RetryPolicy(config filesystem.Config) (models.RetryPolicy, error) {
+	policy := models.DefaultRetryPolicy
+
+	backoff, err := config.GetConnectBackoff()
+	if err != nil {
+		return policy, err
+	}
+
+	maxWait, err := config.GetConnectMaxWait()
+	if err != nil {
+		return policy, err
+	}
+
+	if backoff > 0 {
+		policy.Backoff = backoff
+	}
+
+	if maxWait > 0 {
+		policy.MaxWait = maxWait
+		policy.Attempts = 0
+	}
+
+	if config.ConnectAttempts > 0 {
+		policy.Attempts = config.ConnectAttempts
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,128 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"github.com/djordjev/pg-mig/filesystem"
+	"github.com/djordjev/pg-mig/models"
+	"github.com/djordjev/pg-mig/timer"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	connectError := errors.New("connection refused")
+
+	table := []struct {
+		name         string
+		flags        []string
+		connectError error
+		policy       models.RetryPolicy
+		returnError  bool
+	}{
+		{name: "database is reachable", flags: []string{}, policy: models.RetryPolicy{Backoff: 3 * time.Second, MaxWait: time.Minute}},
+		{name: "custom interval and timeout", flags: []string{"-interval=100ms", "-timeout=0"}, policy: models.RetryPolicy{Backoff: 100 * time.Millisecond}},
+		{name: "database is not reachable", flags: []string{"-timeout=1s"}, connectError: connectError, policy: models.RetryPolicy{Backoff: 3 * time.Second, MaxWait: time.Second}, returnError: true},
+		{name: "zero interval", flags: []string{"-interval=0"}, returnError: true},
+		{name: "negative interval", flags: []string{"-interval=-1s"}, returnError: true},
+		{name: "negative timeout", flags: []string{"-timeout=-1s"}, returnError: true},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			connected := false
+			connector := func(ctx context.Context, str string, policy models.RetryPolicy) (models.DBConnection, error) {
+				r.Equal(backupConnString, str)
+				r.Equal(test.policy, policy)
+				connected = true
+
+				if test.connectError != nil {
+					return nil, test.connectError
+				}
+
+				return &connection{}, nil
+			}
+
+			mp := mockedPrinter{}
+			mp.On("PrintSuccess", mock.Anything)
+
+			wait := Wait{CommandBase: CommandBase{
+				Config:    backupConfig,
+				Flags:     test.flags,
+				Timer:     timer.Timer{Now: buildGetNow("2020-10-20T15:00:00Z")},
+				Printer:   &mp,
+				Connector: connector,
+			}}
+
+			err := wait.Run()
+			if test.returnError {
+				r.Error(err)
+				mp.AssertNotCalled(t, "PrintSuccess", mock.Anything)
+			} else {
+				r.NoError(err)
+				mp.AssertCalled(t, "PrintSuccess", "Database shop is ready after 0s")
+			}
+
+			r.Equal(test.policy != models.RetryPolicy{}, connected)
+
+			if test.connectError != nil {
+				r.True(errors.Is(err, test.connectError))
+				r.Contains(err.Error(), "wait command error: database shop is not reachable")
+			}
+		})
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	table := []struct {
+		name        string
+		config      filesystem.Config
+		policy      models.RetryPolicy
+		returnError bool
+	}{
+		{
+			name:   "defaults",
+			config: filesystem.Config{},
+			policy: models.DefaultRetryPolicy,
+		},
+		{
+			name:   "attempts and backoff",
+			config: filesystem.Config{ConnectAttempts: 10, ConnectBackoff: "500ms"},
+			policy: models.RetryPolicy{Attempts: 10, Backoff: 500 * time.Millisecond},
+		},
+		{
+			name:   "only max wait retries without attempts limit",
+			config: filesystem.Config{ConnectMaxWait: "1m"},
+			policy: models.RetryPolicy{Backoff: models.DefaultRetryPolicy.Backoff, MaxWait: time.Minute},
+		},
+		{
+			name:   "attempts limited by max wait",
+			config: filesystem.Config{ConnectAttempts: 5, ConnectMaxWait: "1m"},
+			policy: models.RetryPolicy{Attempts: 5, Backoff: models.DefaultRetryPolicy.Backoff, MaxWait: time.Minute},
+		},
+		{
+			name:        "invalid backoff",
+			config:      filesystem.Config{ConnectBackoff: "fast"},
+			returnError: true,
+		},
+	}
+
+	for _, test := range table {
+		t.Run(test.name, func(t *testing.T) {
+			r := require.New(t)
+
+			policy, err := RetryPolicy(test.config)
+			if test.returnError {
+				r.Error(err)
+				return
+			}
+
+			r.NoError(err)
+			r.Equal(test.policy, policy)
+		})
+	}
+}